@@ -2,21 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
+	"pr-service/internal/repository/idempotency"
 	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/token"
 	"pr-service/internal/repository/user_team"
 	"syscall"
 	"time"
 
+	"pr-service/internal/auth"
 	"pr-service/internal/config"
 	"pr-service/internal/db"
+	"pr-service/internal/domain"
 	"pr-service/internal/logger"
+	"pr-service/internal/metrics"
+	"pr-service/internal/ratelimit"
+	"pr-service/internal/tracing"
+	"pr-service/migrations"
 
 	"pr-service/internal/service"
 
+	authhand "pr-service/internal/handlers/auth_handlers"
+	healthhand "pr-service/internal/handlers/health_handlers"
+	metricshand "pr-service/internal/handlers/metrics_handlers"
+	appmw "pr-service/internal/handlers/middleware"
 	prhand "pr-service/internal/handlers/pr_handlers"
 	teamhand "pr-service/internal/handlers/team_handlers"
 	userhand "pr-service/internal/handlers/user_handlers"
@@ -27,15 +42,45 @@ import (
 )
 
 func main() {
-	cfg, err := config.Load()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file; environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the resolved configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to load configuration")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	logger.Init(cfg.Logger.Level)
+	if *printConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger.Init(cfg.Logger.Level, cfg.Logger.Format)
+
+	if flag.Arg(0) == "migrate" {
+		migrate(cfg.Database, flag.Args()[1:])
+		return
+	}
 
 	log.Info().Msg("Starting PR Service")
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+
 	dbConfig := config.DatabaseConfig{
 		Host:            cfg.Database.Host,
 		Port:            cfg.Database.Port,
@@ -56,63 +101,241 @@ func main() {
 
 	log.Info().Msg("Database connected successfully")
 
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+	if cfg.Database.MigrateOnStartup {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to migrate database")
+		}
+	}
+
 	teamRepo := user_team.NewUserTeamRepository(database)
 	prRepo := pr.NewPRRepository(database)
+	idempotencyRepo := idempotency.NewIdempotencyRepository(database)
+	tokenRepo := token.NewTokenRepository(database)
+
+	txManager := db.NewTxManager(database)
+
+	registry := metrics.NewRegistry()
+	recorder := metrics.NewRecorder(registry)
+	registry.MustRegister(metrics.DBStats(database), metrics.OpenReviews(prRepo))
+
+	prOpts := []service.PROption{
+		service.WithMetrics(recorder),
+		service.WithReviewerCount(cfg.Reviewer.Count),
+	}
+	if cfg.Reviewer.Strategy == domain.StrategyDiversity {
+		prOpts = append(prOpts, service.WithPairingDiversity(service.PairingDiversity{
+			Window: cfg.Reviewer.HistoryWindow,
+			Decay:  cfg.Reviewer.HistoryDecay,
+		}))
+	}
+	if cfg.Reviewer.SiblingFallback {
+		prOpts = append(prOpts, service.WithSiblingFallback())
+	}
+	if cfg.Reviewer.Seed != 0 {
+		prOpts = append(prOpts, service.WithRandSource(rand.NewSource(cfg.Reviewer.Seed)))
+	}
+	prService := service.NewPRService(prRepo, teamRepo, txManager, prOpts...)
+	teamService := service.NewTeamService(teamRepo, prRepo, txManager, prService)
+	userService := service.NewUserService(teamRepo, prRepo, txManager, prService)
 
-	teamService := service.NewTeamService(teamRepo)
-	userService := service.NewUserService(teamRepo, prRepo)
-	prService := service.NewPRService(prRepo, teamRepo)
+	var authOpts []service.AuthOption
+	if cfg.Auth.JWT.JWKS != "" {
+		keys, err := auth.NewKeySet(context.Background(), cfg.Auth.JWT.JWKS, cfg.Auth.JWT.JWKSRefresh)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load JWKS")
+		}
+		authOpts = append(authOpts, service.WithJWTVerifier(auth.NewJWTVerifier(keys, auth.JWTOptions{
+			Issuer:      cfg.Auth.JWT.Issuer,
+			Audience:    cfg.Auth.JWT.Audience,
+			UserClaim:   cfg.Auth.JWT.UserClaim,
+			GroupsClaim: cfg.Auth.JWT.GroupsClaim,
+			AdminGroups: cfg.Auth.JWT.AdminGroups,
+			UserGroups:  cfg.Auth.JWT.UserGroups,
+			TenantClaim: cfg.Auth.JWT.TenantClaim,
+			Leeway:      cfg.Auth.JWT.Leeway,
+		})))
+		log.Info().Str("jwks", cfg.Auth.JWT.JWKS).Msg("JWT authentication enabled")
+	}
+	authService := service.NewAuthService(tokenRepo, teamRepo, cfg.Auth.BootstrapToken, authOpts...)
+
+	healthService := service.NewHealthService(database, db.NewSchema(database), migrator.Latest())
 
 	teamHandler := teamhand.NewTeamHandler(teamService)
 	userHandler := userhand.NewUserHandler(userService)
 	prHandler := prhand.NewPRHandler(prService)
+	authHandler := authhand.NewAuthHandler(authService)
+	healthHandler := healthhand.NewHealthHandler(healthService)
+	metricsHandler := metricshand.NewMetricsHandler(registry)
 
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Timeout(30 * time.Second))
+	if len(cfg.Server.TrustedProxies) > 0 {
+		r.Use(appmw.RealIP(cfg.Server.TrustedProxies))
+	}
+	r.Use(appmw.Trace)
+	r.Use(appmw.RequestLogger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout))
+	r.Use(appmw.Metrics(recorder))
+
+	healthHandler.RegisterRoutes(r)
+
+	limiter := ratelimit.NewMemory()
+
+	r.Group(func(r chi.Router) {
+		if cfg.RateLimit.Enabled {
+			r.Use(appmw.RateLimitByIP(limiter, rateLimitOf(cfg.RateLimit.PerIP)))
+		}
+		r.Use(appmw.Authenticate(authService))
+		r.Use(appmw.ResolveTenant)
+		if cfg.RateLimit.Enabled {
+			r.Use(appmw.RateLimit(limiter, rateLimitPolicy(cfg.RateLimit)))
+		}
+
+		// Issued secrets must not be stored for replay, so the token routes
+		// stay out of Idempotency.
+		authHandler.RegisterRoutes(r)
+
+		r.Group(func(r chi.Router) {
+			r.Use(appmw.Idempotency(idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout))
 
-	teamHandler.RegisterRoutes(r)
-	userHandler.RegisterRoutes(r)
-	prHandler.RegisterRoutes(r)
+			teamHandler.RegisterRoutes(r)
+			userHandler.RegisterRoutes(r)
+			prHandler.RegisterRoutes(r)
+		})
+	})
 
-	port := getEnv("PORT", "8080")
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", port),
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// Metrics carry every tenant's ids and team names, so they are served on
+	// a separate port that is not exposed with the API.
+	metricsRouter := chi.NewRouter()
+	metricsRouter.Use(middleware.Recoverer)
+	metricsHandler.RegisterRoutes(metricsRouter)
+
+	metricsSrv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.MetricsPort),
+		Handler:      metricsRouter,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeIdempotencyKeys(purgeCtx, idempotencyRepo, cfg.Idempotency.PurgeInterval)
+
 	go func() {
-		log.Info().Str("port", port).Msg("Server starting")
+		log.Info().Int("port", cfg.Server.Port).Msg("Server starting")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed to start")
 		}
 	}()
 
+	go func() {
+		log.Info().Int("port", cfg.Server.MetricsPort).Msg("Metrics server starting")
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Metrics server failed to start")
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
 
+	log.Info().Dur("drain_delay", cfg.Server.DrainDelay).Msg("Draining before shutdown")
+	healthService.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
 	log.Info().Msg("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
+	if err := metricsSrv.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Metrics server forced to shutdown")
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
 
 	log.Info().Msg("Server exited gracefully")
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// purgeIdempotencyKeys deletes expired idempotency keys every interval until
+// ctx is done.
+func purgeIdempotencyKeys(ctx context.Context, repo *idempotency.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := repo.PurgeExpired(ctx, now)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to purge expired idempotency keys")
+				continue
+			}
+			if n > 0 {
+				log.Debug().Int64("purged", n).Msg("Purged expired idempotency keys")
+			}
+		}
+	}
+}
+
+// migrate runs the migrate subcommand and exits on failure.
+func migrate(cfg config.DatabaseConfig, args []string) {
+	database, err := db.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
-	return defaultValue
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	err = runMigrate(context.Background(), migrator, args, os.Stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, err)
+		database.Close()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Migration failed")
+	}
+}
+
+func rateLimitPolicy(cfg config.RateLimitConfig) ratelimit.Policy {
+	policy := ratelimit.Policy{
+		Default: rateLimitOf(cfg.Default),
+		Routes:  make(map[string]ratelimit.Limit, len(cfg.Routes)),
+	}
+	for path, rule := range cfg.Routes {
+		policy.Routes[path] = rateLimitOf(rule)
+	}
+
+	return policy
+}
+
+func rateLimitOf(r config.RateLimitRule) ratelimit.Limit {
+	return ratelimit.Limit{Requests: r.Requests, Per: r.Per, Burst: r.Burst}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"pr-service/internal/db"
+)
+
+const migrateUsage = `usage: pr-service migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down [N]   revert the last N migrations (default 1)
+  status     print the applied version and the pending migrations
+  force V    record version V as applied and clear the dirty flag`
+
+// errUsage marks a malformed migrate command line.
+var errUsage = errors.New(migrateUsage)
+
+// runMigrate runs the migrate subcommand with the arguments after "migrate".
+func runMigrate(ctx context.Context, migrator *db.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "up":
+		if len(rest) != 0 {
+			return errUsage
+		}
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied  %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no change")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		switch len(rest) {
+		case 0:
+		case 1:
+			n, err := strconv.Atoi(rest[0])
+			if err != nil || n < 1 {
+				return errUsage
+			}
+			steps = n
+		default:
+			return errUsage
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(out, "no change")
+		}
+		return nil
+
+	case "status":
+		if len(rest) != 0 {
+			return errUsage
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "version: %d (latest %d)\n", status.Version, migrator.Latest())
+		fmt.Fprintf(out, "dirty:   %t\n", status.Dirty)
+		for _, m := range status.Migrations {
+			state := "applied"
+			if m.Version > status.Version {
+				state = "pending"
+			}
+			fmt.Fprintf(out, "%-8s %03d_%s\n", state, m.Version, m.Name)
+		}
+		return nil
+
+	case "force":
+		if len(rest) != 1 {
+			return errUsage
+		}
+		v, err := strconv.ParseUint(rest[0], 10, 64)
+		if err != nil {
+			return errUsage
+		}
+		if err := migrator.Force(ctx, uint(v)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "version forced to %d\n", v)
+		return nil
+
+	default:
+		return errUsage
+	}
+}
@@ -0,0 +1,199 @@
+// Command prctl operates a running pr-service through its HTTP API.
+//
+//	prctl [-addr URL] [-token TOKEN] [-tenant ID] [-o table|json] <group> <command> [flags] [args]
+//
+// The server address, token, tenant and output format default to
+// PRCTL_ADDR, PRCTL_TOKEN, PRCTL_TENANT and PRCTL_OUTPUT.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"pr-service/internal/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// errUsage is returned by commands called with wrong arguments; run then
+// prints the command's usage.
+var errUsage = errors.New("usage")
+
+// env is what every command runs with.
+type env struct {
+	client *client.Client
+	out    io.Writer
+	output string
+}
+
+type command struct {
+	// name is the group and the command, e.g. "team add"; stats has no
+	// command.
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"team add", "NAME -member ID:USERNAME[:ROLE]... [-parent TEAM]", "create a team with its members", teamAdd},
+	{"team get", "NAME", "show a team and its members", teamGet},
+	{"team list", "[-archived] [-limit N] [-offset N]", "list teams", teamList},
+	{"team deactivate", "NAME", "deactivate every member of a team", teamDeactivate},
+	{"team import", "FILE [-dry-run] [-reassign] [-format csv|yaml]", "apply a CSV or YAML file of teams and members", teamImport},
+	{"team export", "[-format csv|yaml] [-out FILE]", "write all teams and members as CSV or YAML", teamExport},
+	{"user activate", "USER_ID", "mark a user active", userActivate},
+	{"user deactivate", "USER_ID [-reassign]", "mark a user inactive, optionally reassigning their open reviews", userDeactivate},
+	{"pr create", "ID -name NAME -author USER_ID [-lead]", "create a PR and assign reviewers", prCreate},
+	{"pr get", "ID", "show a PR", prGet},
+	{"pr list", "[-status OPEN|MERGED] [-author USER_ID] [-reviewer USER_ID]", "list PRs", prList},
+	{"pr merge", "ID [-version N]", "merge a PR", prMerge},
+	{"pr reassign", "ID -old USER_ID", "replace a reviewer", prReassign},
+	{"stats", "", "PR counts and review load per reviewer", stats},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("prctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", envOr("PRCTL_ADDR", "http://localhost:8080"), "server address")
+	token := fs.String("token", os.Getenv("PRCTL_TOKEN"), "API token")
+	tenant := fs.String("tenant", os.Getenv("PRCTL_TENANT"), "tenant to act in (admin tokens only)")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout")
+	output := fs.String("o", envOr("PRCTL_OUTPUT", outputTable), "output format: table or json")
+	fs.Usage = func() { usage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(stderr, "prctl: unknown output format %q\n", *output)
+		return 2
+	}
+
+	cmd, rest, ok := lookup(fs.Args())
+	if !ok {
+		usage(stderr, fs)
+		return 2
+	}
+
+	var opts []client.Option
+	if *tenant != "" {
+		opts = append(opts, client.WithTenant(*tenant))
+	}
+	e := &env{
+		client: client.New(*addr, *token, opts...),
+		out:    stdout,
+		output: *output,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	err := cmd.run(ctx, e, rest)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "usage: prctl %s %s\n", cmd.name, cmd.args)
+		return 2
+	default:
+		fmt.Fprintln(stderr, "prctl:", err)
+		return 1
+	}
+}
+
+// lookup finds the command named by the first one or two arguments.
+func lookup(args []string) (command, []string, bool) {
+	for _, n := range []int{2, 1} {
+		if len(args) < n {
+			continue
+		}
+		name := strings.Join(args[:n], " ")
+		for _, c := range commands {
+			if c.name == name {
+				return c, args[n:], true
+			}
+		}
+	}
+	return command{}, nil, false
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: prctl [flags] <command> [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	sorted := append([]command(nil), commands...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, c := range sorted {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags:")
+	fs.PrintDefaults()
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// parseArgs parses flags that may come before, after or between the
+// positional arguments and checks that there are exactly want of those.
+func parseArgs(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) != want {
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"pr-service/internal/handlers/dto"
+)
+
+// print writes v as indented JSON, or calls table with a tab-separated
+// writer that is aligned when table returns.
+func (e *env) print(v any, table func(w io.Writer)) error {
+	switch e.output {
+	case outputJSON:
+		enc := json.NewEncoder(e.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputTable:
+		tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", e.output)
+	}
+}
+
+func row(w io.Writer, cols ...any) {
+	for i, c := range cols {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, c)
+	}
+	fmt.Fprintln(w)
+}
+
+func membersTable(w io.Writer, members []dto.UserDTO) {
+	row(w, "USER_ID", "USERNAME", "ROLE", "ACTIVE")
+	for _, m := range members {
+		row(w, m.ID, m.Username, orDash(m.Role), m.IsActive)
+	}
+}
+
+func prsTable(w io.Writer, prs []dto.CreatePullRequestOut) {
+	row(w, "PR_ID", "NAME", "AUTHOR", "STATUS", "REVIEWERS", "VERSION")
+	for _, pr := range prs {
+		row(w, pr.ID, pr.Name, pr.AuthorID, pr.Status, orDash(strings.Join(pr.Reviewers, ",")), pr.Version)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
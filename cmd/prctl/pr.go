@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+)
+
+func prCreate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("pr create", flag.ContinueOnError)
+	name := fs.String("name", "", "PR title")
+	author := fs.String("author", "", "author user ID")
+	lead := fs.Bool("lead", false, "also request the team lead")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *name == "" || *author == "" {
+		return errUsage
+	}
+
+	pr, err := e.client.CreatePR(ctx, dto.CreatePullRequestIn{
+		ID:          pos[0],
+		Name:        *name,
+		AuthorID:    *author,
+		RequestLead: *lead,
+	})
+	if err != nil {
+		return err
+	}
+	return printPR(e, pr)
+}
+
+func prGet(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(flag.NewFlagSet("pr get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	pr, err := e.client.GetPR(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return printPR(e, pr)
+}
+
+func prMerge(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("pr merge", flag.ContinueOnError)
+	version := fs.Int("version", 0, "merge only if the PR is still at this version")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	pr, err := e.client.MergePR(ctx, pos[0], *version)
+	if err != nil {
+		return err
+	}
+	return printPR(e, pr)
+}
+
+func prReassign(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("pr reassign", flag.ContinueOnError)
+	old := fs.String("old", "", "reviewer to replace")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *old == "" {
+		return errUsage
+	}
+
+	pr, err := e.client.ReassignReviewer(ctx, pos[0], *old)
+	if err != nil {
+		return err
+	}
+	return printPR(e, pr)
+}
+
+func printPR(e *env, pr dto.CreatePullRequestOut) error {
+	return e.print(pr, func(w io.Writer) { prsTable(w, []dto.CreatePullRequestOut{pr}) })
+}
+
+func prList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("pr list", flag.ContinueOnError)
+	status := fs.String("status", "", "only PRs with this status")
+	author := fs.String("author", "", "only PRs by this user")
+	reviewer := fs.String("reviewer", "", "only PRs this user reviews")
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *status != "" && *status != string(domain.PRStatusOpen) && *status != string(domain.PRStatusMerged) {
+		return errUsage
+	}
+
+	var prs []dto.CreatePullRequestOut
+	if *reviewer != "" {
+		out, err := e.client.GetUserReviews(ctx, *reviewer)
+		if err != nil {
+			return err
+		}
+		prs = out.PullRequests
+	} else {
+		out, err := e.client.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		prs = out.PullRequests
+	}
+
+	filtered := make([]dto.CreatePullRequestOut, 0, len(prs))
+	for _, pr := range prs {
+		if *status != "" && string(pr.Status) != *status {
+			continue
+		}
+		if *author != "" && pr.AuthorID != *author {
+			continue
+		}
+		filtered = append(filtered, pr)
+	}
+
+	return e.print(filtered, func(w io.Writer) { prsTable(w, filtered) })
+}
+
+type statsOut struct {
+	Total     int           `json:"total_pull_requests"`
+	Open      int           `json:"open"`
+	Merged    int           `json:"merged"`
+	Reviewers []reviewerOut `json:"reviewers"`
+}
+
+type reviewerOut struct {
+	UserID string `json:"user_id"`
+	Open   int    `json:"open"`
+	Total  int    `json:"total"`
+}
+
+func stats(ctx context.Context, e *env, args []string) error {
+	if _, err := parseArgs(flag.NewFlagSet("stats", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	all, err := e.client.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := statsOut{Total: all.Total, Reviewers: []reviewerOut{}}
+	byReviewer := make(map[string]*reviewerOut)
+	for _, pr := range all.PullRequests {
+		open := pr.Status == domain.PRStatusOpen
+		if open {
+			out.Open++
+		} else {
+			out.Merged++
+		}
+		for _, id := range pr.Reviewers {
+			r, ok := byReviewer[id]
+			if !ok {
+				r = &reviewerOut{UserID: id}
+				byReviewer[id] = r
+			}
+			r.Total++
+			if open {
+				r.Open++
+			}
+		}
+	}
+	for _, r := range byReviewer {
+		out.Reviewers = append(out.Reviewers, *r)
+	}
+	// Busiest reviewers first.
+	sort.Slice(out.Reviewers, func(i, j int) bool {
+		a, b := out.Reviewers[i], out.Reviewers[j]
+		if a.Open != b.Open {
+			return a.Open > b.Open
+		}
+		return a.UserID < b.UserID
+	})
+
+	return e.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "total:\t%d\nopen:\t%d\nmerged:\t%d\n\n", out.Total, out.Open, out.Merged)
+		row(w, "REVIEWER", "OPEN", "TOTAL")
+		for _, r := range out.Reviewers {
+			row(w, r.UserID, r.Open, r.Total)
+		}
+	})
+}
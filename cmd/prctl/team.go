@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pr-service/internal/client"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/orgchart"
+)
+
+func teamAdd(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("team add", flag.ContinueOnError)
+	parent := fs.String("parent", "", "parent team")
+	var members stringList
+	fs.Var(&members, "member", "member as ID:USERNAME[:ROLE]; repeat for each member")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return errUsage
+	}
+
+	in := dto.CreateTeamIn{Name: pos[0], ParentName: *parent}
+	for _, m := range members {
+		member, err := parseMember(m)
+		if err != nil {
+			return err
+		}
+		in.Members = append(in.Members, member)
+	}
+
+	team, err := e.client.CreateTeam(ctx, in)
+	if err != nil {
+		return err
+	}
+	return e.print(team, func(w io.Writer) { teamTable(w, team) })
+}
+
+// parseMember reads ID:USERNAME[:ROLE]; the member is active.
+func parseMember(s string) (dto.UserDTO, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return dto.UserDTO{}, fmt.Errorf("member %q: want ID:USERNAME[:ROLE]", s)
+	}
+
+	m := dto.UserDTO{ID: parts[0], Username: parts[1], IsActive: true}
+	if len(parts) == 3 {
+		m.Role = parts[2]
+	}
+	return m, nil
+}
+
+func teamGet(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(flag.NewFlagSet("team get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	team, err := e.client.GetTeam(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.print(team, func(w io.Writer) { teamTable(w, team) })
+}
+
+func teamTable(w io.Writer, team dto.CreateTeamOut) {
+	fmt.Fprintf(w, "team:\t%s\n", team.Name)
+	if team.ParentName != "" {
+		fmt.Fprintf(w, "parent:\t%s\n", team.ParentName)
+	}
+	if team.ArchivedAt != nil {
+		fmt.Fprintf(w, "archived:\t%s\n", team.ArchivedAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintln(w)
+	membersTable(w, team.Members)
+}
+
+func teamList(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("team list", flag.ContinueOnError)
+	archived := fs.Bool("archived", false, "include archived teams")
+	limit := fs.Int("limit", 0, "page size (server default when 0)")
+	offset := fs.Int("offset", 0, "teams to skip")
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	list, err := e.client.ListTeams(ctx, *archived, *limit, *offset)
+	if err != nil {
+		return err
+	}
+	return e.print(list, func(w io.Writer) {
+		row(w, "TEAM", "MEMBERS", "ACTIVE", "ARCHIVED")
+		for _, t := range list.Teams {
+			archived := "-"
+			if t.ArchivedAt != nil {
+				archived = t.ArchivedAt.Format("2006-01-02")
+			}
+			row(w, t.Name, t.MemberCount, t.ActiveCount, archived)
+		}
+		p := list.Pagination
+		fmt.Fprintf(w, "\n%d-%d of %d\n", min(p.Offset+1, p.Total), p.Offset+len(list.Teams), p.Total)
+	})
+}
+
+func teamDeactivate(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(flag.NewFlagSet("team deactivate", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	out, err := e.client.DeactivateTeam(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.print(out, func(w io.Writer) {
+		fmt.Fprintf(w, "team %s deactivated\n", pos[0])
+	})
+}
+
+func teamImport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("team import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "show the changes without applying them")
+	reassign := fs.Bool("reassign", false, "reassign the open reviews of removed members")
+	format := fs.String("format", "", "csv or yaml (from the file extension when empty)")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = orgchart.FormatOf(filepath.Ext(pos[0]))
+	}
+	if *format != orgchart.CSV && *format != orgchart.YAML {
+		return fmt.Errorf("%s: cannot tell the format, use -format csv|yaml", pos[0])
+	}
+
+	f, err := os.Open(pos[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	result, err := e.client.ImportTeams(ctx, *format, f, *dryRun, *reassign)
+	if err != nil {
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) && len(apiErr.Issues) > 0 {
+			var b strings.Builder
+			for _, issue := range apiErr.Issues {
+				b.WriteString("\n  ")
+				if issue.Line > 0 {
+					fmt.Fprintf(&b, "%s:%d: ", pos[0], issue.Line)
+				}
+				if issue.TeamName != "" {
+					fmt.Fprintf(&b, "team %s: ", issue.TeamName)
+				}
+				if issue.UserID != "" {
+					fmt.Fprintf(&b, "user %s: ", issue.UserID)
+				}
+				b.WriteString(issue.Message)
+			}
+			return fmt.Errorf("%w%s", err, b.String())
+		}
+		return err
+	}
+
+	return e.print(result, func(w io.Writer) {
+		row(w, "TEAM", "CHANGE", "PARENT", "ADDED", "UPDATED", "REMOVED", "REASSIGNED")
+		for _, t := range result.Teams {
+			change := "-"
+			switch {
+			case t.Created:
+				change = "create"
+			case t.ParentChanged || len(t.Added)+len(t.Updated)+len(t.Removed) > 0:
+				change = "update"
+			}
+			parent := orDash(t.ParentName)
+			if t.ParentChanged && !t.Created {
+				parent = orDash(t.OldParentName) + " -> " + parent
+			}
+			row(w, t.TeamName, change, parent, userIDs(t.Added), userIDs(t.Updated), userIDs(t.Removed), len(t.ReassignedPRs))
+		}
+
+		if result.DryRun {
+			fmt.Fprintf(w, "\ndry run: %d of %d teams would change, nothing was applied\n", result.Changed, len(result.Teams))
+		} else {
+			fmt.Fprintf(w, "\n%d of %d teams changed\n", result.Changed, len(result.Teams))
+		}
+	})
+}
+
+func userIDs(users []dto.UserDTO) string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return orDash(strings.Join(ids, ","))
+}
+
+func teamExport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("team export", flag.ContinueOnError)
+	format := fs.String("format", "", "csv or yaml (from the -out extension when empty, else csv)")
+	out := fs.String("out", "", "file to write instead of standard output")
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *format == "" && *out != "" {
+		*format = orgchart.FormatOf(filepath.Ext(*out))
+	}
+	if *format == "" {
+		*format = orgchart.CSV
+	}
+	if *format != orgchart.CSV && *format != orgchart.YAML {
+		return fmt.Errorf("unknown format %q, want csv or yaml", *format)
+	}
+
+	if *out == "" {
+		return e.client.ExportTeams(ctx, *format, e.out)
+	}
+
+	// Write next to the target and rename, so a failed export does not
+	// leave a truncated file behind.
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".prctl-export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := e.client.ExportTeams(ctx, *format, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), *out)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"pr-service/internal/handlers/dto"
+)
+
+func userActivate(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(flag.NewFlagSet("user activate", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	return setUserActive(ctx, e, dto.SetUserActiveIn{UserID: pos[0], IsActive: true})
+}
+
+func userDeactivate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("user deactivate", flag.ContinueOnError)
+	reassign := fs.Bool("reassign", false, "reassign the user's open reviews")
+
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	return setUserActive(ctx, e, dto.SetUserActiveIn{UserID: pos[0], ReassignReviews: *reassign})
+}
+
+func setUserActive(ctx context.Context, e *env, in dto.SetUserActiveIn) error {
+	out, err := e.client.SetUserActive(ctx, in)
+	if err != nil {
+		return err
+	}
+	return e.print(out, func(w io.Writer) {
+		row(w, "USER_ID", "USERNAME", "TEAM", "ROLE", "ACTIVE")
+		row(w, out.User.ID, out.User.Username, orDash(out.User.TeamName), orDash(out.User.Role), out.User.IsActive)
+		if len(out.ReassignedPRs) > 0 {
+			fmt.Fprintln(w, "\nreassigned:")
+			prsTable(w, out.ReassignedPRs)
+		}
+	})
+}
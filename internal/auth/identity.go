@@ -0,0 +1,36 @@
+// Package auth carries the authenticated caller through the request context.
+package auth
+
+import (
+	"context"
+
+	"pr-service/internal/domain"
+)
+
+// Identity is the caller resolved from the request credentials.
+type Identity struct {
+	TokenID string
+	Scope   domain.Scope
+	// UserID is the user the credentials belong to; empty for service
+	// tokens.
+	UserID string
+	// TenantID is the tenant the credentials are bound to. It is empty only
+	// for the bootstrap token, which may act in any tenant.
+	TenantID string
+}
+
+func (i Identity) IsAdmin() bool {
+	return i.Scope == domain.ScopeAdmin
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity stored by WithIdentity.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
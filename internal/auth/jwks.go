@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"pr-service/internal/logger"
+)
+
+// minJWKSRefetch bounds how often the set is fetched again, both after an
+// unknown kid and after a failed refresh.
+const minJWKSRefetch = time.Minute
+
+var (
+	errNoKey          = errors.New("no matching key")
+	errUnsupportedKey = errors.New("unsupported key")
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet holds the signing keys of a JWKS document loaded from a file or an
+// http(s) URL. It is reloaded after refresh, and earlier when a token names a
+// key id the set does not know. A failed reload keeps the last good keys.
+type KeySet struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        []publicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	// loading is closed when the fetch in flight finishes.
+	loading chan struct{}
+}
+
+// NewKeySet loads the JWKS from source, a file path or an http(s) URL.
+func NewKeySet(ctx context.Context, source string, refresh time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	keys, err := ks.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ks.keys = keys
+	ks.loadedAt = time.Now()
+	ks.attemptedAt = ks.loadedAt
+
+	return ks, nil
+}
+
+// key returns the key for kid, or the only key usable with alg when the token
+// has no kid.
+func (ks *KeySet) key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	stale := ks.refresh > 0 && time.Since(ks.loadedAt) > ks.refresh
+	ks.mu.Unlock()
+
+	if stale {
+		ks.reload(ctx, true)
+	}
+
+	if k := ks.find(kid, alg); k != nil {
+		return k, nil
+	}
+
+	if kid != "" && ks.reload(ctx, false) {
+		if k := ks.find(kid, alg); k != nil {
+			return k, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for kid %q and alg %s", errNoKey, kid, alg)
+}
+
+// reload fetches the set again unless the last attempt was less than
+// minJWKSRefetch ago; a stale set whose last load succeeded is fetched right
+// away. Concurrent callers wait for a single fetch, and ks.mu is not held
+// while it runs. It reports whether the keys were replaced; a failure is
+// logged and the previous keys stay in use.
+func (ks *KeySet) reload(ctx context.Context, stale bool) bool {
+	ks.mu.Lock()
+	if wait := ks.loading; wait != nil {
+		ks.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return false
+		}
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		return ks.loadedAt.Equal(ks.attemptedAt)
+	}
+	due := time.Since(ks.attemptedAt) >= minJWKSRefetch || (stale && ks.loadedAt.Equal(ks.attemptedAt))
+	if !due {
+		ks.mu.Unlock()
+		return false
+	}
+	done := make(chan struct{})
+	ks.loading = done
+	ks.mu.Unlock()
+
+	// The fetch is shared, so one caller giving up must not cancel it.
+	keys, err := ks.load(context.WithoutCancel(ctx))
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.attemptedAt = time.Now()
+	if err == nil {
+		ks.keys = keys
+		ks.loadedAt = ks.attemptedAt
+	} else {
+		logger.FromContext(ctx).Warn().Err(err).Str("source", ks.source).Msg("jwks refresh failed, keeping the previous keys")
+	}
+	ks.loading = nil
+	close(done)
+
+	return err == nil
+}
+
+func (ks *KeySet) find(kid, alg string) crypto.PublicKey {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	var match crypto.PublicKey
+	count := 0
+	for _, k := range ks.keys {
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if !keyFits(k.key, alg) {
+			continue
+		}
+		if kid != "" {
+			if k.kid == kid {
+				return k.key
+			}
+			continue
+		}
+		match = k.key
+		count++
+	}
+
+	if count == 1 {
+		return match
+	}
+	return nil
+}
+
+// load fetches and parses the set. Keys of an unsupported type or curve are
+// skipped, so that one new key at the provider does not break the others.
+func (ks *KeySet) load(ctx context.Context) ([]publicKey, error) {
+	raw, err := ks.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make([]publicKey, 0, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", k.Kid, err)
+		}
+		keys = append(keys, publicKey{kid: k.Kid, alg: k.Alg, key: pub})
+	}
+
+	return keys, nil
+}
+
+func (ks *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(ks.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return pub, nil
+
+	default:
+		return nil, fmt.Errorf("%w: type %q", errUnsupportedKey, k.Kty)
+	}
+}
+
+func keyFits(key crypto.PublicKey, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == algRS256
+	case *ecdsa.PublicKey:
+		return alg == algES256
+	default:
+		return false
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/tenant"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+
+	// jwtTokenPrefix marks identities resolved from a JWT; the rest is the
+	// tenant and user id, so that the identity stays the same across token
+	// refreshes.
+	jwtTokenPrefix = "jwt:"
+)
+
+// JWTOptions controls which tokens are accepted and how their claims map to
+// an identity.
+type JWTOptions struct {
+	// Issuer and Audience are checked against "iss" and "aud" when set.
+	Issuer   string
+	Audience string
+	// UserClaim holds the user id; "sub" by default.
+	UserClaim string
+	// GroupsClaim holds the caller's groups, a string or a list of strings;
+	// "groups" by default.
+	GroupsClaim string
+	// Members of AdminGroups get the admin scope, everyone else the user
+	// scope.
+	AdminGroups []string
+	// When UserGroups is set, callers outside it and AdminGroups are
+	// rejected.
+	UserGroups []string
+	// TenantClaim holds the tenant id. When empty, every token belongs to
+	// the default tenant.
+	TenantClaim string
+	// Leeway is the allowed clock skew for "exp" and "nbf".
+	Leeway time.Duration
+}
+
+// JWTVerifier validates RS256 and ES256 tokens against a KeySet.
+type JWTVerifier struct {
+	keys *KeySet
+	opts JWTOptions
+	now  func() time.Time
+}
+
+func NewJWTVerifier(keys *KeySet, opts JWTOptions) *JWTVerifier {
+	if opts.UserClaim == "" {
+		opts.UserClaim = "sub"
+	}
+	if opts.GroupsClaim == "" {
+		opts.GroupsClaim = "groups"
+	}
+	return &JWTVerifier{keys: keys, opts: opts, now: time.Now}
+}
+
+// IsJWT reports whether token has the three-part compact JWS shape. Opaque
+// API tokens never contain dots.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks the signature and the registered claims of token and maps
+// it to an identity. Invalid tokens yield an error wrapping
+// domain.ErrUnauthorized; failures to load the key set are returned as is.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, invalidJWT("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, invalidJWT("malformed header")
+	}
+	if header.Alg != algRS256 && header.Alg != algES256 {
+		return Identity{}, invalidJWT("unsupported alg " + header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, invalidJWT("malformed signature")
+	}
+
+	key, err := v.keys.key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		if errors.Is(err, errNoKey) {
+			return Identity{}, invalidJWT(err.Error())
+		}
+		return Identity{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(key, digest[:], sig) {
+		return Identity{}, invalidJWT("bad signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, invalidJWT("malformed claims")
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	return v.identity(claims)
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return invalidJWT("missing exp")
+	}
+	if now.After(exp.Add(v.opts.Leeway)) {
+		return invalidJWT("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.opts.Leeway).Before(nbf) {
+		return invalidJWT("token not valid yet")
+	}
+
+	if v.opts.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.opts.Issuer {
+			return invalidJWT("unexpected issuer")
+		}
+	}
+	if v.opts.Audience != "" && !slices.Contains(stringList(claims["aud"]), v.opts.Audience) {
+		return invalidJWT("unexpected audience")
+	}
+
+	return nil
+}
+
+func (v *JWTVerifier) identity(claims map[string]any) (Identity, error) {
+	userID, _ := claims[v.opts.UserClaim].(string)
+	if userID == "" {
+		return Identity{}, invalidJWT("missing " + v.opts.UserClaim + " claim")
+	}
+
+	groups := stringList(claims[v.opts.GroupsClaim])
+	inAny := func(allowed []string) bool {
+		return slices.ContainsFunc(groups, func(g string) bool { return slices.Contains(allowed, g) })
+	}
+
+	scope := domain.ScopeUser
+	switch {
+	case inAny(v.opts.AdminGroups):
+		scope = domain.ScopeAdmin
+	case len(v.opts.UserGroups) > 0 && !inAny(v.opts.UserGroups):
+		return Identity{}, invalidJWT("caller is in none of the allowed groups")
+	}
+
+	tenantID := tenant.Default
+	if v.opts.TenantClaim != "" {
+		tenantID, _ = claims[v.opts.TenantClaim].(string)
+		if !tenant.IsValid(tenantID) {
+			return Identity{}, invalidJWT("missing or invalid " + v.opts.TenantClaim + " claim")
+		}
+	}
+
+	return Identity{
+		TokenID:  jwtTokenPrefix + tenantID + ":" + userID,
+		Scope:    scope,
+		UserID:   userID,
+		TenantID: tenantID,
+	}, nil
+}
+
+func verifySignature(key crypto.PublicKey, digest, sig []byte) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		// JWS uses the fixed-size r || s encoding rather than ASN.1.
+		if len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, digest, r, s)
+	default:
+		return false
+	}
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+// stringList accepts a claim that is either a single string or a list.
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func invalidJWT(reason string) error {
+	return fmt.Errorf("%w: %s", domain.ErrUnauthorized, reason)
+}
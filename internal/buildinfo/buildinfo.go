@@ -0,0 +1,58 @@
+// Package buildinfo reports what binary is running. Version, Commit and
+// BuildTime can be set at link time:
+//
+//	go build -ldflags "-X pr-service/internal/buildinfo.Version=1.2.0" ./cmd/app
+//
+// Commit and BuildTime fall back to the VCS stamp of the Go toolchain.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string
+	Commit    string
+	BuildTime string
+	// Modified is set when the binary was built from a dirty work tree.
+	Modified  bool
+	GoVersion string
+}
+
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+
+	return info
+}
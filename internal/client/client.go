@@ -0,0 +1,287 @@
+// Package client is a Go client for the HTTP API of the service, used by
+// prctl. Requests and responses are the handler DTOs, so the client cannot
+// drift from the server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/orgchart"
+)
+
+// TenantHeader selects the tenant for admin tokens, as in the middleware.
+const TenantHeader = "X-Tenant-ID"
+
+// APIError is a non-2xx response in the API's error format.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	// Issues lists the rejected lines of an import file.
+	Issues []dto.ImportIssueOut
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	tenant  string
+	http    *http.Client
+}
+
+type Option func(*Client)
+
+// WithTenant sends requests on behalf of tenant; only admin tokens may
+// choose one.
+func WithTenant(tenant string) Option {
+	return func(c *Client) {
+		c.tenant = tenant
+	}
+}
+
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// New returns a client for the server at baseURL that authenticates with
+// the bearer token, if any.
+func New(baseURL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends in as JSON and decodes a 2xx response into out. The header
+// may be nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, in, out any) error {
+	h := http.Header{"Accept": {"application/json"}}
+	for k, v := range header {
+		h[k] = v
+	}
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+		h.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.send(ctx, method, path, query, h, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends the request and returns a 2xx response, which the caller must
+// close; any other status is returned as an *APIError.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.tenant != "" {
+		req.Header.Set(TenantHeader, c.tenant)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+func decodeError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	// ImportErrorOut is the common error format plus the issues that only
+	// /team/import sends.
+	var errResp dto.ImportErrorOut
+	if err := json.Unmarshal(b, &errResp); err == nil && errResp.Error.Code != "" {
+		return &APIError{
+			Status:  resp.StatusCode,
+			Code:    errResp.Error.Code,
+			Message: errResp.Error.Message,
+			Issues:  errResp.Error.Issues,
+		}
+	}
+	return &APIError{Status: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+}
+
+func (c *Client) CreateTeam(ctx context.Context, in dto.CreateTeamIn) (dto.CreateTeamOut, error) {
+	var out dto.TeamWrapper
+	err := c.do(ctx, http.MethodPost, "/team/add", nil, nil, in, &out)
+	return out.Team, err
+}
+
+func (c *Client) GetTeam(ctx context.Context, name string) (dto.CreateTeamOut, error) {
+	var out dto.CreateTeamOut
+	err := c.do(ctx, http.MethodGet, "/team/get", url.Values{"team_name": {name}}, nil, nil, &out)
+	return out, err
+}
+
+// ListTeams returns one page of teams; a zero limit leaves the server's
+// default.
+func (c *Client) ListTeams(ctx context.Context, includeArchived bool, limit, offset int) (dto.TeamListOut, error) {
+	query := url.Values{}
+	if includeArchived {
+		query.Set("include_archived", "true")
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var out dto.TeamListOut
+	err := c.do(ctx, http.MethodGet, "/team/list", query, nil, nil, &out)
+	return out, err
+}
+
+// DeactivateTeam deactivates every member of the team.
+func (c *Client) DeactivateTeam(ctx context.Context, name string) (dto.DeactivateOut, error) {
+	var out dto.DeactivateOut
+	err := c.do(ctx, http.MethodPost, "/deactivate", url.Values{"team_name": {name}}, nil, nil, &out)
+	return out, err
+}
+
+func (c *Client) SetUserActive(ctx context.Context, in dto.SetUserActiveIn) (dto.UserWrapper, error) {
+	var out dto.UserWrapper
+	err := c.do(ctx, http.MethodPost, "/users/setIsActive", nil, nil, in, &out)
+	return out, err
+}
+
+func (c *Client) GetUserReviews(ctx context.Context, userID string) (dto.GetUserReviewsOut, error) {
+	var out dto.GetUserReviewsOut
+	err := c.do(ctx, http.MethodGet, "/users/getReview", url.Values{"user_id": {userID}}, nil, nil, &out)
+	return out, err
+}
+
+func (c *Client) CreatePR(ctx context.Context, in dto.CreatePullRequestIn) (dto.CreatePullRequestOut, error) {
+	var out dto.PullRequestWrapper
+	err := c.do(ctx, http.MethodPost, "/pullRequest/create", nil, nil, in, &out)
+	return out.PR, err
+}
+
+func (c *Client) GetPR(ctx context.Context, id string) (dto.CreatePullRequestOut, error) {
+	var out dto.PullRequestWrapper
+	err := c.do(ctx, http.MethodGet, "/pullRequest/get", url.Values{"pull_request_id": {id}}, nil, nil, &out)
+	return out.PR, err
+}
+
+// MergePR merges the PR. A non-zero version is sent as If-Match, so the
+// merge fails if the PR changed since it was read.
+func (c *Client) MergePR(ctx context.Context, id string, version int) (dto.CreatePullRequestOut, error) {
+	var header http.Header
+	if version > 0 {
+		header = http.Header{"If-Match": {strconv.Quote(strconv.Itoa(version))}}
+	}
+
+	var out dto.PullRequestWrapper
+	err := c.do(ctx, http.MethodPost, "/pullRequest/merge", nil, header, dto.MergePullRequest{ID: id}, &out)
+	return out.PR, err
+}
+
+func (c *Client) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (dto.CreatePullRequestOut, error) {
+	in := dto.ReassignReviewerRequest{PullRequestID: prID, OldReviewerID: oldReviewerID}
+
+	var out dto.PullRequestWrapper
+	err := c.do(ctx, http.MethodPost, "/pullRequest/reassign", nil, nil, in, &out)
+	return out.PR, err
+}
+
+// ImportTeams uploads a team file in format, csv or yaml. With dryRun the
+// server reports the changes without applying them.
+func (c *Client) ImportTeams(ctx context.Context, format string, file io.Reader, dryRun, reassignReviews bool) (dto.ImportOut, error) {
+	query := url.Values{"format": {format}}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+	if reassignReviews {
+		query.Set("reassign_reviews", "true")
+	}
+
+	var out dto.ImportOut
+	resp, err := c.send(ctx, http.MethodPost, "/team/import", query, http.Header{
+		"Accept":       {"application/json"},
+		"Content-Type": {orgchart.ContentType(format)},
+	}, file)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, fmt.Errorf("decode response of POST /team/import: %w", err)
+	}
+	return out, nil
+}
+
+// ExportTeams writes the teams in format, csv or yaml, to w.
+func (c *Client) ExportTeams(ctx context.Context, format string, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, "/team/export", url.Values{"format": {format}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *Client) Stats(ctx context.Context) (dto.StatsOut, error) {
+	var out dto.StatsOut
+	err := c.do(ctx, http.MethodGet, "/stats", nil, nil, nil, &out)
+	return out, err
+}
@@ -3,17 +3,27 @@ package config
 import (
 	"fmt"
 	"log"
-	"os"
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
 
+	"pr-service/internal/domain"
+
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Logger   LoggerConfig
+	settings []Setting
+
+	Database    DatabaseConfig
+	Server      ServerConfig
+	Logger      LoggerConfig
+	Reviewer    ReviewerConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	Tracing     TracingConfig
 }
 
 type DatabaseConfig struct {
@@ -26,14 +36,192 @@ type DatabaseConfig struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// MigrateOnStartup applies pending migrations before serving.
+	MigrateOnStartup bool
 }
 
 type ServerConfig struct {
-	Port string
+	Port int
+	// MetricsPort serves /metrics on its own listener, kept off the public
+	// API port.
+	MetricsPort  int
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// RequestTimeout cancels the context of a request that runs longer.
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long the server keeps serving after it starts
+	// reporting not ready, so probes notice before connections are closed.
+	DrainDelay time.Duration
+	// TrustedProxies may set the client address in X-Forwarded-For.
+	TrustedProxies []netip.Prefix
 }
 
 type LoggerConfig struct {
 	Level string
+	// Format is "json" or "console".
+	Format string
+}
+
+type ReviewerConfig struct {
+	Strategy string
+	// Count is how many reviewers a new PR gets.
+	Count           int
+	HistoryWindow   int
+	HistoryDecay    float64
+	Seed            int64
+	SiblingFallback bool
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration
+	// PurgeInterval is how often expired keys are deleted.
+	PurgeInterval time.Duration
+	// LockTimeout is how long a request holds its key before a retry may
+	// take it over.
+	LockTimeout time.Duration
+}
+
+type AuthConfig struct {
+	// BootstrapToken is accepted as an admin token without being stored.
+	BootstrapToken string
+	JWT            JWTConfig
+}
+
+// JWTConfig enables SSO tokens when JWKS is set.
+type JWTConfig struct {
+	// JWKS is a file path or an http(s) URL of the signing keys.
+	JWKS        string
+	JWKSRefresh time.Duration
+	Issuer      string
+	Audience    string
+	UserClaim   string
+	GroupsClaim string
+	AdminGroups []string
+	UserGroups  []string
+	TenantClaim string
+	Leeway      time.Duration
+}
+
+// TracingConfig selects where spans go: "none", "otlp" (OTLP/HTTP),
+// "stdout" or "file".
+type TracingConfig struct {
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	File         string
+	ServiceName  string
+	SampleRatio  float64
+}
+
+// RateLimitConfig limits requests per client. Routes lists paths with their
+// own limits; the rest share Default. PerIP limits all requests of a client
+// IP before they are authenticated. A zero rule means no limit.
+type RateLimitConfig struct {
+	Enabled bool
+	Default RateLimitRule
+	Routes  map[string]RateLimitRule
+	PerIP   RateLimitRule
+}
+
+// RateLimitRule allows Requests per Per with bursts of up to Burst
+// (Requests when zero). It is written as "<requests>/<s|m|h>[:<burst>]",
+// e.g. "10/s:20".
+type RateLimitRule struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func ParseRateLimitRule(s string) (RateLimitRule, error) {
+	spec, burstStr, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	reqStr, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q: want <requests>/<s|m|h>[:<burst>]", s)
+	}
+
+	var rule RateLimitRule
+
+	switch unit {
+	case "s":
+		rule.Per = time.Second
+	case "m":
+		rule.Per = time.Minute
+	case "h":
+		rule.Per = time.Hour
+	default:
+		return RateLimitRule{}, fmt.Errorf("rate limit %q: unknown period %q", s, unit)
+	}
+
+	n, err := strconv.Atoi(reqStr)
+	if err != nil || n < 0 {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q: invalid request count", s)
+	}
+	rule.Requests = n
+
+	if hasBurst {
+		b, err := strconv.Atoi(burstStr)
+		if err != nil || b < 1 {
+			return RateLimitRule{}, fmt.Errorf("rate limit %q: invalid burst", s)
+		}
+		rule.Burst = b
+	}
+
+	return rule, nil
+}
+
+func loadRateLimit(l *loader) RateLimitConfig {
+	cfg := RateLimitConfig{
+		Enabled: l.Bool("RATE_LIMIT_ENABLED", true),
+		Routes:  make(map[string]RateLimitRule),
+	}
+
+	var err error
+	cfg.Default, err = ParseRateLimitRule(l.String("RATE_LIMIT_DEFAULT", "50/s:100"))
+	if err != nil {
+		l.fail("RATE_LIMIT_DEFAULT", "%v", err)
+	}
+
+	cfg.PerIP, err = ParseRateLimitRule(l.String("RATE_LIMIT_PER_IP", "100/s:200"))
+	if err != nil {
+		l.fail("RATE_LIMIT_PER_IP", "%v", err)
+	}
+
+	for _, item := range l.List("RATE_LIMIT_ROUTES", []string{"/pullRequest/create=20/s:40"}) {
+		path, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			l.fail("RATE_LIMIT_ROUTES", "%q: want <path>=<limit>", item)
+			continue
+		}
+		rule, err := ParseRateLimitRule(spec)
+		if err != nil {
+			l.fail("RATE_LIMIT_ROUTES", "%v", err)
+			continue
+		}
+		cfg.Routes[strings.TrimSpace(path)] = rule
+	}
+
+	return cfg
+}
+
+// loadTrustedProxies reads addresses and CIDR ranges; a bare address
+// stands for itself.
+func loadTrustedProxies(l *loader) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range l.List("SERVER_TRUSTED_PROXIES", nil) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			l.fail("SERVER_TRUSTED_PROXIES", "%q is neither an address nor a CIDR range", item)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
 }
 
 func (c DatabaseConfig) ConnString() string {
@@ -43,53 +231,136 @@ func (c DatabaseConfig) ConnString() string {
 	)
 }
 
-func Load() (*Config, error) {
+// Load reads the configuration from the environment (and .env), layered
+// over the optional YAML config file at path, layered over the defaults. It
+// reports every invalid value at once.
+func Load(path string) (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		log.Println("Note: No .env file found, using environment variables")
 	}
 
-	return &Config{
+	l := &loader{}
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+	}
+
+	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:            GetEnv("DB_HOST", "localhost"),
-			Port:            GetEnvAsInt("DB_PORT", 5432),
-			User:            GetEnv("DB_USER", "postgres"),
-			Password:        GetEnv("DB_PASSWORD", "postgres"),
-			DBName:          GetEnv("DB_NAME", "pr_service"),
-			SSLMode:         GetEnv("DB_SSLMODE", "disable"),
-			MaxOpenConns:    GetEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    GetEnvAsInt("DB_MAX_IDLE_CONNS", 5),
-			ConnMaxLifetime: GetEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			Host:             l.String("DB_HOST", "localhost"),
+			Port:             l.Int("DB_PORT", 5432),
+			User:             l.String("DB_USER", "postgres"),
+			Password:         l.Secret("DB_PASSWORD", "postgres"),
+			DBName:           l.String("DB_NAME", "pr_service"),
+			SSLMode:          l.OneOf("DB_SSLMODE", "disable", "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+			MaxOpenConns:     l.Int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:     l.Int("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime:  l.Duration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			MigrateOnStartup: l.Bool("DB_MIGRATE_ON_STARTUP", false),
 		},
 		Server: ServerConfig{
-			Port: GetEnv("SERVER_PORT", "8080"),
+			Port:            l.Int("SERVER_PORT", 8080),
+			MetricsPort:     l.Int("SERVER_METRICS_PORT", 9464),
+			ReadTimeout:     l.Duration("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:    l.Duration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:     l.Duration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			RequestTimeout:  l.Duration("SERVER_REQUEST_TIMEOUT", 30*time.Second),
+			ShutdownTimeout: l.Duration("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second),
+			DrainDelay:      l.Duration("SERVER_DRAIN_DELAY", 5*time.Second),
+			TrustedProxies:  loadTrustedProxies(l),
 		},
 		Logger: LoggerConfig{
-			Level: GetEnv("LOG_LEVEL", "info"),
+			Level:  l.OneOf("LOG_LEVEL", "info", "debug", "info", "warn", "error"),
+			Format: l.OneOf("LOG_FORMAT", "json", "json", "console"),
 		},
-	}, nil
-}
+		Reviewer: ReviewerConfig{
+			Strategy:        l.OneOf("REVIEWER_STRATEGY", domain.StrategyRandom, domain.StrategyRandom, domain.StrategyDiversity),
+			Count:           l.Int("REVIEWER_COUNT", 2),
+			HistoryWindow:   l.Int("REVIEWER_HISTORY_WINDOW", 10),
+			HistoryDecay:    l.Float("REVIEWER_HISTORY_DECAY", 0.7),
+			Seed:            int64(l.Int("REVIEWER_SEED", 0)),
+			SiblingFallback: l.Bool("REVIEWER_SIBLING_FALLBACK", false),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           l.Duration("IDEMPOTENCY_TTL", 24*time.Hour),
+			PurgeInterval: l.Duration("IDEMPOTENCY_PURGE_INTERVAL", 10*time.Minute),
+			LockTimeout:   l.Duration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+		},
+		Auth: AuthConfig{
+			BootstrapToken: l.Secret("AUTH_BOOTSTRAP_TOKEN", ""),
+			JWT: JWTConfig{
+				JWKS:        l.String("AUTH_JWKS", ""),
+				JWKSRefresh: l.Duration("AUTH_JWKS_REFRESH", 10*time.Minute),
+				Issuer:      l.String("AUTH_JWT_ISSUER", ""),
+				Audience:    l.String("AUTH_JWT_AUDIENCE", ""),
+				UserClaim:   l.String("AUTH_JWT_USER_CLAIM", "sub"),
+				GroupsClaim: l.String("AUTH_JWT_GROUPS_CLAIM", "groups"),
+				AdminGroups: l.List("AUTH_JWT_ADMIN_GROUPS", nil),
+				UserGroups:  l.List("AUTH_JWT_USER_GROUPS", nil),
+				TenantClaim: l.String("AUTH_JWT_TENANT_CLAIM", ""),
+				Leeway:      l.Duration("AUTH_JWT_LEEWAY", 30*time.Second),
+			},
+		},
+		RateLimit: loadRateLimit(l),
+		Tracing: TracingConfig{
+			Exporter:     l.OneOf("TRACING_EXPORTER", "none", "none", "otlp", "stdout", "file"),
+			OTLPEndpoint: l.String("TRACING_OTLP_ENDPOINT", ""),
+			OTLPInsecure: l.Bool("TRACING_OTLP_INSECURE", false),
+			File:         l.String("TRACING_FILE", "traces.jsonl"),
+			ServiceName:  l.String("TRACING_SERVICE_NAME", "pr-service"),
+			SampleRatio:  l.Float("TRACING_SAMPLE_RATIO", 1),
+		},
+	}
+
+	cfg.validate(l)
+	cfg.settings = l.settings
 
-func GetEnv(key, defaultVal string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	if err := l.err(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
 	}
-	return defaultVal
+
+	return cfg, nil
 }
 
-func GetEnvAsInt(key string, defaultVal int) int {
-	valueStr := GetEnv(key, "")
-	if valueStr == "" {
-		return defaultVal
-	}
-	value, _ := strconv.Atoi(valueStr)
-	return value
+// validate checks ranges and combinations that parse fine on their own.
+func (c *Config) validate(l *loader) {
+	l.check(c.Database.Port > 0 && c.Database.Port <= 65535, "DB_PORT", "must be between 1 and 65535")
+	l.check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative")
+	l.check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative")
+	l.check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative")
+
+	l.check(c.Server.Port > 0 && c.Server.Port <= 65535, "SERVER_PORT", "must be between 1 and 65535")
+	l.check(c.Server.MetricsPort > 0 && c.Server.MetricsPort <= 65535, "SERVER_METRICS_PORT", "must be between 1 and 65535")
+	l.check(c.Server.MetricsPort != c.Server.Port, "SERVER_METRICS_PORT", "must differ from SERVER_PORT")
+	l.check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT", "must be positive")
+	l.check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT", "must be positive")
+	l.check(c.Server.IdleTimeout > 0, "SERVER_IDLE_TIMEOUT", "must be positive")
+	l.check(c.Server.RequestTimeout > 0, "SERVER_REQUEST_TIMEOUT", "must be positive")
+	l.check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT", "must be positive")
+	l.check(c.Server.DrainDelay >= 0, "SERVER_DRAIN_DELAY", "must not be negative")
+
+	l.check(c.Reviewer.Count >= 1, "REVIEWER_COUNT", "must be at least 1")
+	l.check(c.Reviewer.HistoryWindow >= 1, "REVIEWER_HISTORY_WINDOW", "must be positive")
+	l.check(c.Reviewer.HistoryDecay > 0 && c.Reviewer.HistoryDecay <= 1, "REVIEWER_HISTORY_DECAY", "must be in (0, 1]")
+
+	l.check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL", "must be positive")
+	l.check(c.Idempotency.PurgeInterval > 0, "IDEMPOTENCY_PURGE_INTERVAL", "must be positive")
+	l.check(c.Idempotency.LockTimeout > c.Server.RequestTimeout, "IDEMPOTENCY_LOCK_TIMEOUT", "must be longer than SERVER_REQUEST_TIMEOUT")
+
+	// The token shipped in older .env files is public, and it would let
+	// anyone act as admin in any tenant.
+	l.check(c.Auth.BootstrapToken != "dev-admin-token", "AUTH_BOOTSTRAP_TOKEN", "is the published example token, set a random one")
+	l.check(c.Auth.JWT.JWKSRefresh > 0, "AUTH_JWKS_REFRESH", "must be positive")
+	l.check(c.Auth.JWT.Leeway >= 0, "AUTH_JWT_LEEWAY", "must not be negative")
+
+	l.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be in [0, 1]")
+	l.check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "TRACING_FILE", "is required for the file exporter")
 }
 
-func GetEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
-	valueStr := GetEnv(key, "")
-	if valueStr == "" {
-		return defaultVal
-	}
-	value, _ := time.ParseDuration(valueStr)
-	return value
+// Settings lists every value Load resolved, in the order it read them.
+func (c *Config) Settings() []Setting {
+	return c.settings
 }
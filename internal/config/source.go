@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting came from, as shown by --print-config.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Setting is one resolved configuration value.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// loader reads settings by their environment variable name. A value set in
+// the environment wins over the config file, which wins over the default.
+// Invalid values are collected rather than replaced by the default, so that
+// Load can report all of them at once.
+type loader struct {
+	file     map[string]string
+	settings []Setting
+	errs     []error
+}
+
+// readFile flattens a YAML config file into environment variable names:
+// nested keys are joined with "_" and upper-cased, so "db: {host: x}" and
+// "DB_HOST: x" set the same value. Sequences become comma-separated lists.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", root, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, node map[string]any, out map[string]string) error {
+	for k, v := range node {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func (l *loader) lookup(key, def string, secret bool) string {
+	value, source := def, SourceDefault
+	if v, ok := l.file[key]; ok {
+		value, source = v, SourceFile
+	}
+	if v := os.Getenv(key); v != "" {
+		value, source = v, SourceEnv
+	}
+
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source, Secret: secret})
+	return value
+}
+
+func (l *loader) fail(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+}
+
+func (l *loader) String(key, def string) string {
+	return l.lookup(key, def, false)
+}
+
+// Secret reads a value that --print-config must not show.
+func (l *loader) Secret(key, def string) string {
+	return l.lookup(key, def, true)
+}
+
+func (l *loader) Int(key string, def int) int {
+	s := l.lookup(key, strconv.Itoa(def), false)
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		l.fail(key, "invalid integer %q", s)
+		return def
+	}
+	return v
+}
+
+func (l *loader) Float(key string, def float64) float64 {
+	s := l.lookup(key, strconv.FormatFloat(def, 'g', -1, 64), false)
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		l.fail(key, "invalid number %q", s)
+		return def
+	}
+	return v
+}
+
+func (l *loader) Bool(key string, def bool) bool {
+	s := l.lookup(key, strconv.FormatBool(def), false)
+	v, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		l.fail(key, "invalid boolean %q", s)
+		return def
+	}
+	return v
+}
+
+func (l *loader) Duration(key string, def time.Duration) time.Duration {
+	s := l.lookup(key, def.String(), false)
+	v, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		l.fail(key, "invalid duration %q", s)
+		return def
+	}
+	return v
+}
+
+// List splits a comma-separated value, dropping empty items.
+func (l *loader) List(key string, def []string) []string {
+	s := l.lookup(key, strings.Join(def, ","), false)
+
+	var values []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// OneOf reads a string that must be one of allowed.
+func (l *loader) OneOf(key, def string, allowed ...string) string {
+	s := l.String(key, def)
+	for _, a := range allowed {
+		if s == a {
+			return s
+		}
+	}
+	l.fail(key, "%q is not one of %s", s, strings.Join(allowed, ", "))
+	return def
+}
+
+// check records an error for key unless ok holds.
+func (l *loader) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		l.fail(key, format, args...)
+	}
+}
+
+// err reports every invalid value and every key in the config file that is
+// not a known setting.
+func (l *loader) err() error {
+	known := make(map[string]bool, len(l.settings))
+	for _, s := range l.settings {
+		known[s.Key] = true
+	}
+
+	var unknown []string
+	for key := range l.file {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	errs := l.errs
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("config file: unknown setting %s", key))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"pr-service/internal/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationLockID is the key of the Postgres advisory lock held while
+// migrating, so that replicas started together apply each migration once.
+const migrationLockID int64 = 0x70725f6d6967 // "pr_mig"
+
+// ErrDirtySchema is returned when a previous migration failed halfway and
+// the schema has to be fixed by hand before running `migrate force`.
+var ErrDirtySchema = errors.New("schema is dirty")
+
+// MigrationStatus is the applied version against the embedded migrations.
+type MigrationStatus struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Pending returns the migrations above the applied version.
+func (s MigrationStatus) Pending() []Migration {
+	for i, m := range s.Migrations {
+		if m.Version > s.Version {
+			return s.Migrations[i:]
+		}
+	}
+	return nil
+}
+
+// Migrator applies and reverts migrations. The state is kept in the
+// schema_migrations table of golang-migrate, so databases migrated by the
+// migrate/migrate container carry on from where it stopped.
+//
+// Every migration runs in its own transaction together with the version
+// update: a failing migration leaves the schema at the previous version.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func NewMigrator(database *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: database, migrations: migrations}, nil
+}
+
+// Latest is the version the schema reaches after Up.
+func (m *Migrator) Latest() uint {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	version, dirty, err := schemaVersion(ctx, m.db)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	return MigrationStatus{Version: version, Dirty: dirty, Migrations: m.migrations}, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("schema version %d is newer than the latest migration %d", version, m.Latest())
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig, mig.Up, mig.Version); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns them in the
+// order they were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		i, err := m.index(version)
+		if err != nil {
+			return err
+		}
+
+		for ; i >= 0 && len(reverted) < steps; i-- {
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			mig := m.migrations[i]
+			if err := m.apply(ctx, conn, mig, mig.Down, previous); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force records version as applied and clears the dirty flag without
+// running anything. Version 0 marks the database as never migrated.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 {
+		if _, err := m.index(version); err != nil {
+			return err
+		}
+	}
+
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin tx: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// index returns the position of the migration with version, -1 for 0.
+func (m *Migrator) index(version uint) (int, error) {
+	if version == 0 {
+		return -1, nil
+	}
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown migration version %d", version)
+}
+
+func (m *Migrator) cleanVersion(ctx context.Context, conn *sqlx.Conn) (uint, error) {
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d: fix it and run migrate force", ErrDirtySchema, version)
+	}
+	return version, nil
+}
+
+// apply runs one migration body and records version in the same
+// transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, mig Migration, body string, version uint) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %d_%s: commit: %w", mig.Version, mig.Name, err)
+	}
+
+	logger.FromContext(ctx).Info().
+		Uint("migration", mig.Version).
+		Str("name", mig.Name).
+		Uint("version", version).
+		Msg("migration applied")
+
+	return nil
+}
+
+func setVersion(ctx context.Context, tx *sqlx.Tx, version uint) error {
+	const createTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)`
+
+	if _, err := tx.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("set schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, version); err != nil {
+		return fmt.Errorf("set schema version: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn on a connection holding the migration advisory lock,
+// waiting for another migrator to finish first. The lock belongs to the
+// session, so everything in fn must use conn.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// A fresh context: the lock must be released even when ctx is done,
+		// or the pooled connection would keep it.
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			logger.FromContext(ctx).Warn().Err(err).Msg("release migration lock")
+		}
+	}()
+
+	return fn(conn)
+}
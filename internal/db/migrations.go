@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const undefinedTable = "42P01"
+
+// Migration is one schema change, read from "<version>_<name>.up.sql" and
+// "<version>_<name>.down.sql".
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads the migrations in fsys ordered by version. Every
+// migration must have both an up and a down file.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, file := range files {
+		base, direction, ok := cutDirection(file)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: want a .up.sql or .down.sql suffix", file)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", file)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		m, ok := byVersion[uint(v)]
+		if !ok {
+			m = &Migration{Version: uint(v), Name: name}
+			byVersion[uint(v)] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d: names %q and %q differ", v, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func cutDirection(file string) (string, string, bool) {
+	file = path.Base(file)
+	if base, ok := strings.CutSuffix(file, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(file, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// LatestMigration returns the highest migration version in fsys, the schema
+// version this build expects.
+func LatestMigration(fsys fs.FS) (uint, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Schema reads the migration state kept in schema_migrations, in the format
+// of golang-migrate: one row with the version and a dirty flag.
+type Schema struct {
+	db *sqlx.DB
+}
+
+func NewSchema(database *sqlx.DB) *Schema {
+	return &Schema{db: database}
+}
+
+// Version returns the applied schema version and whether the last migration
+// failed halfway. A database that was never migrated is at version 0.
+func (s *Schema) Version(ctx context.Context) (uint, bool, error) {
+	return schemaVersion(ctx, s.db)
+}
+
+func schemaVersion(ctx context.Context, q sqlx.QueryerContext) (uint, bool, error) {
+	const query = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+
+	err := sqlx.GetContext(ctx, q, &row, query)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == undefinedTable) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("query schema version: %w", err)
+	}
+
+	return uint(row.Version), row.Dirty, nil
+}
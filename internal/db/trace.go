@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+
+	"pr-service/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedQuerier starts a client span for every statement. The span is named
+// after the repository method that issued it, so a slow request shows which
+// query took the time.
+type tracedQuerier struct {
+	q Querier
+}
+
+func (t tracedQuerier) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, span := startQuery(ctx, query)
+	err := t.q.GetContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+func (t tracedQuerier) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, span := startQuery(ctx, query)
+	err := t.q.SelectContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	res, err := t.q.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return res, err
+}
+
+func (t tracedQuerier) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	res, err := t.q.NamedExecContext(ctx, query, arg)
+	endQuery(span, err)
+	return res, err
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	query = strings.TrimSpace(query)
+	operation, _, _ := strings.Cut(query, " ")
+
+	return tracing.Tracer().Start(ctx, callerName(), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(strings.ToUpper(operation)),
+			semconv.DBQueryText(query),
+		))
+}
+
+// endQuery does not mark "no rows" as a failure: repositories use it to
+// report a missing record.
+func endQuery(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// callerName returns the first function outside this package on the stack,
+// shortened to "pkg.Type.Method". Queries run in a closure, e.g. inside
+// WithinTx, are named after the enclosing method.
+func callerName() string {
+	pcs := make([]uintptr, 8)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "pr-service/internal/db.") {
+			name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			for {
+				rest, last, _ := strings.Cut(name, ".func")
+				if rest == name || strings.Trim(last, "0123456789.") != "" {
+					return name
+				}
+				name = rest
+			}
+		}
+		if !more {
+			return "db.query"
+		}
+	}
+}
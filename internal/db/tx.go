@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pr-service/internal/logger"
+	"pr-service/internal/tracing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Querier is the part of the sqlx API shared by *sqlx.DB and *sqlx.Tx that
+// repositories rely on.
+type Querier interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+type txKey struct{}
+
+// Conn returns the transaction carried by ctx, or database when ctx has none.
+func Conn(ctx context.Context, database *sqlx.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tracedQuerier{q: tx}
+	}
+	return tracedQuerier{q: database}
+}
+
+// WithinTx runs fn inside a transaction stored in the context passed to fn.
+// If ctx already carries a transaction fn joins it and the outermost call
+// decides whether to commit. The transaction is rolled back when fn returns
+// an error or panics.
+func WithinTx(ctx context.Context, database *sqlx.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "db.Transaction")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := database.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.FromContext(ctx).Warn().Err(rbErr).AnErr("cause", err).Msg("rollback failed")
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// TxManager lets services run several repository calls in one transaction.
+type TxManager struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(database *sqlx.DB) *TxManager {
+	return &TxManager{db: database}
+}
+
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithinTx(ctx, m.db, fn)
+}
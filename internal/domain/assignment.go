@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+type AssignmentKind string
+
+const (
+	AssignmentCreate   AssignmentKind = "CREATE"
+	AssignmentReassign AssignmentKind = "REASSIGN"
+)
+
+// Reviewer selection strategies, as configured by REVIEWER_STRATEGY and
+// recorded in assignment traces.
+const (
+	StrategyRandom    = "random"
+	StrategyDiversity = "diversity"
+)
+
+type ExclusionReason string
+
+const (
+	ExcludedAuthor          ExclusionReason = "AUTHOR"
+	ExcludedInactive        ExclusionReason = "INACTIVE"
+	ExcludedAlreadyAssigned ExclusionReason = "ALREADY_ASSIGNED"
+	ExcludedObserver        ExclusionReason = "OBSERVER"
+)
+
+type AssignmentCandidate struct {
+	UserID   string
+	Excluded ExclusionReason
+	Weight   float64
+}
+
+func (c AssignmentCandidate) IsEligible() bool {
+	return c.Excluded == ""
+}
+
+// AssignmentTrace records how reviewers were picked for a PR so that the
+// selection can be explained and replayed from the same seed.
+type AssignmentTrace struct {
+	PRID               string
+	Kind               AssignmentKind
+	Strategy           string
+	Seed               int64
+	ReplacedReviewerID string
+	Candidates         []AssignmentCandidate
+	Picked             []string
+	// FallbackTeams lists the sibling teams the candidates came from when
+	// the author's team had nobody eligible.
+	FallbackTeams []string
+	// LeadID is the team lead picked on request; it is also in Picked.
+	LeadID    string
+	CreatedAt time.Time
+}
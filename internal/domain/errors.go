@@ -3,13 +3,25 @@ package domain
 import "errors"
 
 const (
-	ErrCodeTeamExists  = "TEAM_EXISTS"
-	ErrCodePRExists    = "PR_EXISTS"
-	ErrCodePRMerged    = "PR_MERGED"
-	ErrCodeNotAssigned = "NOT_ASSIGNED"
-	ErrCodeNoCandidate = "NO_CANDIDATE"
-	ErrCodeNotFound    = "NOT_FOUND"
-	ErrCodeInvalidData = "INVALID_DATA"
+	ErrCodeTeamExists       = "TEAM_EXISTS"
+	ErrCodeTeamArchived     = "TEAM_ARCHIVED"
+	ErrCodeTeamHasOpenPRs   = "TEAM_HAS_OPEN_PRS"
+	ErrCodeTeamCycle        = "TEAM_CYCLE"
+	ErrCodePRExists         = "PR_EXISTS"
+	ErrCodePRMerged         = "PR_MERGED"
+	ErrCodeNotAssigned      = "NOT_ASSIGNED"
+	ErrCodeNoCandidate      = "NO_CANDIDATE"
+	ErrCodeReviewerConflict = "REVIEWER_CONFLICT"
+	ErrCodeVersionMismatch  = "VERSION_MISMATCH"
+	ErrCodeIdempotencyReuse = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeIdempotencyBusy  = "IDEMPOTENCY_IN_PROGRESS"
+	ErrCodeUnauthorized     = "UNAUTHORIZED"
+	ErrCodeForbidden        = "FORBIDDEN"
+	ErrCodeRateLimited      = "RATE_LIMITED"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeInvalidData      = "INVALID_DATA"
+	ErrCodeInvalidImport    = "INVALID_IMPORT"
+	ErrCodeUserInOtherTeam  = "USER_IN_OTHER_TEAM"
 )
 
 type ErrorDetail struct {
@@ -28,6 +40,23 @@ var (
 	ErrPRMerged          = errors.New("cannot reassign on merged PR")
 	ErrNotAssigned       = errors.New("reviewer is not assigned to this PR")
 	ErrNoCandidate       = errors.New("no active replacement candidate in team")
+	ErrReviewerConflict  = errors.New("reviewer assignment changed concurrently")
+	ErrVersionMismatch   = errors.New("pull request version does not match If-Match")
+	ErrIdempotencyReuse  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyBusy   = errors.New("request with this idempotency key is still in progress")
+	ErrNotTeamMember     = errors.New("user is not a member of the team")
+	ErrTeamArchived      = errors.New("team is archived")
+	ErrTeamHasOpenPRs    = errors.New("team has open pull requests")
+	ErrTeamCycle         = errors.New("parent team would create a cycle")
+	ErrUserInOtherTeam   = errors.New("user belongs to another team, move them with /users/move")
+	ErrInvalidRole       = errors.New("role must be one of lead, member, observer")
+	ErrInvalidScope      = errors.New("scope must be admin or user")
+	ErrTokenNeedsUser    = errors.New("user tokens must belong to a user")
+	ErrUnauthorized      = errors.New("missing or invalid bearer token")
+	ErrForbidden         = errors.New("not allowed for this token")
+	ErrInvalidTenant     = errors.New("tenant id must be 1-64 lowercase letters, digits, '-' or '_'")
+	ErrTenantMismatch    = errors.New("token belongs to another tenant")
+	ErrRateLimited       = errors.New("too many requests, retry later")
 )
 
 func NewErrorResponse(code, message string) ErrorResponse {
@@ -0,0 +1,14 @@
+package domain
+
+// HealthCheck is the result of one readiness check.
+type HealthCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// Readiness tells whether the instance should receive traffic.
+type Readiness struct {
+	Ready  bool
+	Checks []HealthCheck
+}
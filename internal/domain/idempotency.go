@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is a request fingerprint reserved under an
+// Idempotency-Key together with the response once it is known.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+	// LockedUntil is when a reservation without a response may be taken
+	// over by a retry. It also tells the reservation apart from a later one
+	// of the same key.
+	LockedUntil time.Time
+}
+
+func (r IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
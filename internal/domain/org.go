@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImportTeam is a team read from an import file. Line is where the team is
+// first mentioned, for error reports. KeepParent is set when the file does
+// not give the parent at all, e.g. a CSV without the parent_name column; the
+// current parent is then left as it is.
+type ImportTeam struct {
+	Team
+	Line       int
+	KeepParent bool
+}
+
+// ImportIssue is a problem with one line of an import file.
+type ImportIssue struct {
+	Line    int
+	Team    string
+	UserID  string
+	Message string
+}
+
+func (i ImportIssue) String() string {
+	var b strings.Builder
+	if i.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", i.Line)
+	}
+	if i.Team != "" {
+		fmt.Fprintf(&b, "team %s: ", i.Team)
+	}
+	if i.UserID != "" {
+		fmt.Fprintf(&b, "user %s: ", i.UserID)
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ImportIssues rejects an import file; nothing of it is applied.
+type ImportIssues []ImportIssue
+
+func (e ImportIssues) Error() string {
+	lines := make([]string, len(e))
+	for i, issue := range e {
+		lines[i] = issue.String()
+	}
+	return "invalid import: " + strings.Join(lines, "; ")
+}
+
+type ImportOptions struct {
+	// DryRun computes the changes without keeping them.
+	DryRun bool
+	// ReassignReviews hands over the open reviews of removed members.
+	ReassignReviews bool
+}
+
+// TeamImport is what an import changed in one team. OldParent is the parent
+// before the import; it differs from Diff.Team.ParentName when the team was
+// moved.
+type TeamImport struct {
+	Diff       TeamDiff
+	OldParent  string
+	Reassigned []PullRequest
+}
+
+func (t TeamImport) ParentChanged() bool {
+	return t.OldParent != t.Diff.Team.ParentName
+}
+
+// Changed reports whether the import touched the team at all.
+func (t TeamImport) Changed() bool {
+	d := t.Diff
+	return d.Created || t.ParentChanged() || len(d.Added) > 0 || len(d.Updated) > 0 || len(d.Removed) > 0
+}
+
+type ImportResult struct {
+	DryRun bool
+	Teams  []TeamImport
+}
@@ -0,0 +1,7 @@
+package domain
+
+// Page selects a window of a sorted listing.
+type Page struct {
+	Limit  int
+	Offset int
+}
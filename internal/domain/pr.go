@@ -16,6 +16,9 @@ type PullRequestCreate struct {
 	ID       string
 	Name     string
 	AuthorID string
+	// RequestLead asks for a team lead among the reviewers, on top of the
+	// regular picks unless one of them already is a lead.
+	RequestLead bool
 }
 
 type PullRequest struct {
@@ -26,6 +29,7 @@ type PullRequest struct {
 	AssignedReviewers []string
 	CreatedAt         time.Time
 	MergedAt          *time.Time
+	Version           int
 }
 
 func (pr *PullRequest) Merge() error {
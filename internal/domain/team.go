@@ -1,6 +1,69 @@
 package domain
 
+import "time"
+
 type Team struct {
-	Name    string
-	Members []User
+	Name       string
+	ParentName string
+	Members    []User
+	ArchivedAt *time.Time
+}
+
+// IsArchived reports whether the team was retired. Archived teams are
+// read-only and their members are inactive.
+func (t Team) IsArchived() bool {
+	return t.ArchivedAt != nil
+}
+
+// TeamDiff is the outcome of syncing a team with a desired member list.
+type TeamDiff struct {
+	Team    Team
+	Created bool
+	Added   []User
+	Updated []User
+	Removed []User
+}
+
+// TeamSummary is a team as shown in listings.
+type TeamSummary struct {
+	Name        string
+	MemberCount int
+	ActiveCount int
+	ArchivedAt  *time.Time
+}
+
+// TeamStats are the counters shown for a node of the team tree.
+type TeamStats struct {
+	Members       int
+	ActiveMembers int
+	OpenPRs       int
+	MergedPRs     int
+}
+
+func (s TeamStats) Add(other TeamStats) TeamStats {
+	return TeamStats{
+		Members:       s.Members + other.Members,
+		ActiveMembers: s.ActiveMembers + other.ActiveMembers,
+		OpenPRs:       s.OpenPRs + other.OpenPRs,
+		MergedPRs:     s.MergedPRs + other.MergedPRs,
+	}
+}
+
+// TeamNode is a team in the hierarchy. Own counts the team's direct members
+// and their PRs, Total adds up Own over the whole subtree.
+type TeamNode struct {
+	Name       string
+	ParentName string
+	ArchivedAt *time.Time
+	Own        TeamStats
+	Total      TeamStats
+	Children   []*TeamNode
+}
+
+// TeamReviewLoad is the number of reviews assigned to members of a team on
+// open PRs.
+type TeamReviewLoad struct {
+	TenantID    string
+	TeamName    string
+	OpenReviews int
 }
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// Scope is what a bearer token is allowed to do. Admin covers team and user
+// management; user covers PR work and reading.
+type Scope string
+
+const (
+	ScopeAdmin Scope = "admin"
+	ScopeUser  Scope = "user"
+)
+
+func (s Scope) IsValid() bool {
+	return s == ScopeAdmin || s == ScopeUser
+}
+
+// APIToken is an issued bearer token. Only the hash of the secret is kept.
+type APIToken struct {
+	ID        string
+	TenantID  string
+	Name      string
+	Hash      string
+	Scope     Scope
+	UserID    string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (t APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+type TokenIssue struct {
+	Name   string
+	Scope  Scope
+	UserID string
+}
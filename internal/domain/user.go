@@ -1,13 +1,40 @@
 package domain
 
+// Role is a user's role within their team. Observers follow the team but
+// are never picked as reviewers.
+type Role string
+
+const (
+	RoleLead     Role = "lead"
+	RoleMember   Role = "member"
+	RoleObserver Role = "observer"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleLead, RoleMember, RoleObserver:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
 	ID       string
 	Username string
 	IsActive bool
 	TeamName string
+	Role     Role
 }
 
 type ActivateUserRequest struct {
 	UserID   string
 	IsActive bool
 }
+
+// UserFilter narrows a user search. Empty fields match everything.
+type UserFilter struct {
+	UsernamePrefix string
+	TeamName       string
+	IsActive       *bool
+}
@@ -0,0 +1,83 @@
+package authhand
+
+import (
+	"errors"
+	"net/http"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/handlers/mapper"
+	appmw "pr-service/internal/handlers/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AuthHandler struct {
+	authService AuthService
+}
+
+func NewAuthHandler(authService AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+func (h *AuthHandler) RegisterRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.RequireScope(domain.ScopeAdmin))
+
+		r.Post("/auth/issueToken", h.IssueToken)
+		r.Post("/auth/revokeToken", h.RevokeToken)
+	})
+}
+
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.IssueTokenIn](w, r)
+	if !ok {
+		return
+	}
+
+	secret, token, err := h.authService.Issue(r.Context(), domain.TokenIssue{
+		Name:   req.Name,
+		Scope:  domain.Scope(req.Scope),
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidScope) || errors.Is(err, domain.ErrTokenNeedsUser) {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusCreated, dto.IssueTokenOut{
+		Secret: secret,
+		Token:  mapper.TokenToResponse(token),
+	})
+}
+
+func (h *AuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.RevokeTokenIn](w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.authService.Revoke(r.Context(), req.TokenID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "token not found")
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TokenWrapper{Token: mapper.TokenToResponse(token)})
+}
@@ -0,0 +1,11 @@
+package authhand
+
+import (
+	"context"
+	"pr-service/internal/domain"
+)
+
+type AuthService interface {
+	Issue(ctx context.Context, req domain.TokenIssue) (string, domain.APIToken, error)
+	Revoke(ctx context.Context, id string) (domain.APIToken, error)
+}
@@ -1,13 +1,17 @@
 package dto
 
-import "pr-service/internal/domain"
+import (
+	"pr-service/internal/domain"
+	"time"
+)
 
 type PRStatus = domain.PRStatus
 
 type CreatePullRequestIn struct {
-	ID       string `json:"pull_request_id" validate:"required"`
-	Name     string `json:"pull_request_name" validate:"required"`
-	AuthorID string `json:"author_id" validate:"required"`
+	ID          string `json:"pull_request_id" validate:"required"`
+	Name        string `json:"pull_request_name" validate:"required"`
+	AuthorID    string `json:"author_id" validate:"required"`
+	RequestLead bool   `json:"request_lead"`
 }
 
 type PullRequestWrapper struct {
@@ -20,6 +24,36 @@ type CreatePullRequestOut struct {
 	AuthorID  string   `json:"author_id"`
 	Status    PRStatus `json:"status"`
 	Reviewers []string `json:"assigned_reviewers"`
+	Version   int      `json:"version"`
+}
+
+type AssignmentCandidateOut struct {
+	UserID         string  `json:"user_id"`
+	Eligible       bool    `json:"eligible"`
+	ExcludedReason string  `json:"excluded_reason,omitempty"`
+	Weight         float64 `json:"weight,omitempty"`
+}
+
+type AssignmentOut struct {
+	Kind               string                   `json:"kind"`
+	Strategy           string                   `json:"strategy"`
+	Seed               int64                    `json:"seed"`
+	ReplacedReviewerID string                   `json:"replaced_reviewer_id,omitempty"`
+	Candidates         []AssignmentCandidateOut `json:"candidates"`
+	Picked             []string                 `json:"picked"`
+	FallbackTeams      []string                 `json:"fallback_teams,omitempty"`
+	LeadID             string                   `json:"lead_id,omitempty"`
+	CreatedAt          time.Time                `json:"created_at"`
+}
+
+type ExplainAssignmentOut struct {
+	PullRequestID string          `json:"pull_request_id"`
+	Assignments   []AssignmentOut `json:"assignments"`
+}
+
+type StatsOut struct {
+	Total        int                    `json:"total_pull_requests"`
+	PullRequests []CreatePullRequestOut `json:"pull_requests"`
 }
 
 type MergePullRequest struct {
@@ -32,12 +66,25 @@ type ReassignReviewerRequest struct {
 }
 
 type SetUserActiveIn struct {
+	UserID          string `json:"user_id" validate:"required"`
+	IsActive        bool   `json:"is_active"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type MoveUserIn struct {
+	UserID          string `json:"user_id" validate:"required"`
+	TeamName        string `json:"team_name" validate:"required"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type UpdateUserIn struct {
 	UserID   string `json:"user_id" validate:"required"`
-	IsActive bool   `json:"is_active"`
+	Username string `json:"username" validate:"required"`
 }
 
 type UserWrapper struct {
-	User UserDTO `json:"user"`
+	User          UserDTO                `json:"user"`
+	ReassignedPRs []CreatePullRequestOut `json:"reassigned_pull_requests,omitempty"`
 }
 
 type UserDTO struct {
@@ -45,6 +92,7 @@ type UserDTO struct {
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
 	TeamName string `json:"team_name,omitempty"`
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=lead member observer"`
 }
 
 type GetUserReviewsOut struct {
@@ -53,13 +101,146 @@ type GetUserReviewsOut struct {
 }
 
 type CreateTeamIn struct {
-	Name    string    `json:"team_name" validate:"required"`
-	Members []UserDTO `json:"members" validate:"required,min=1,dive"`
+	Name       string    `json:"team_name" validate:"required"`
+	ParentName string    `json:"parent_name,omitempty"`
+	Members    []UserDTO `json:"members" validate:"required,min=1,dive"`
+}
+
+type AddTeamMembersIn struct {
+	TeamName string    `json:"team_name" validate:"required"`
+	Members  []UserDTO `json:"members" validate:"required,min=1,dive"`
+}
+
+type RemoveTeamMemberIn struct {
+	TeamName        string `json:"team_name" validate:"required"`
+	UserID          string `json:"user_id" validate:"required"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type SyncTeamIn struct {
+	Name            string    `json:"team_name" validate:"required"`
+	Members         []UserDTO `json:"members" validate:"required,dive"`
+	ReassignReviews bool      `json:"reassign_reviews"`
+}
+
+type SyncTeamOut struct {
+	Team          CreateTeamOut          `json:"team"`
+	Created       bool                   `json:"created"`
+	Added         []UserDTO              `json:"added"`
+	Updated       []UserDTO              `json:"updated"`
+	Removed       []UserDTO              `json:"removed"`
+	ReassignedPRs []CreatePullRequestOut `json:"reassigned_pull_requests,omitempty"`
+}
+
+type TeamImportOut struct {
+	TeamName      string                 `json:"team_name"`
+	Created       bool                   `json:"created"`
+	ParentName    string                 `json:"parent_name,omitempty"`
+	OldParentName string                 `json:"old_parent_name,omitempty"`
+	ParentChanged bool                   `json:"parent_changed"`
+	Added         []UserDTO              `json:"added"`
+	Updated       []UserDTO              `json:"updated"`
+	Removed       []UserDTO              `json:"removed"`
+	ReassignedPRs []CreatePullRequestOut `json:"reassigned_pull_requests,omitempty"`
+}
+
+type ImportOut struct {
+	DryRun  bool            `json:"dry_run"`
+	Changed int             `json:"changed"`
+	Teams   []TeamImportOut `json:"teams"`
+}
+
+type ImportIssueOut struct {
+	Line     int    `json:"line,omitempty"`
+	TeamName string `json:"team_name,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+type ImportErrorDetail struct {
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Issues  []ImportIssueOut `json:"issues"`
+}
+
+type ImportErrorOut struct {
+	Error ImportErrorDetail `json:"error"`
+}
+
+type PaginationOut struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+type TeamSummaryOut struct {
+	Name        string     `json:"team_name"`
+	MemberCount int        `json:"member_count"`
+	ActiveCount int        `json:"active_count"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+}
+
+type TeamListOut struct {
+	Teams      []TeamSummaryOut `json:"teams"`
+	Pagination PaginationOut    `json:"pagination"`
+}
+
+type UserSearchOut struct {
+	Users      []UserDTO     `json:"users"`
+	Pagination PaginationOut `json:"pagination"`
+}
+
+type SetTeamRoleIn struct {
+	TeamName        string `json:"team_name" validate:"required"`
+	UserID          string `json:"user_id" validate:"required"`
+	Role            string `json:"role" validate:"required,oneof=lead member observer"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type SetTeamParentIn struct {
+	TeamName   string `json:"team_name" validate:"required"`
+	ParentName string `json:"parent_name"`
+}
+
+type TeamStatsOut struct {
+	Members       int `json:"members"`
+	ActiveMembers int `json:"active_members"`
+	OpenPRs       int `json:"open_pull_requests"`
+	MergedPRs     int `json:"merged_pull_requests"`
+}
+
+type TeamNodeOut struct {
+	Name       string         `json:"team_name"`
+	ParentName string         `json:"parent_name,omitempty"`
+	ArchivedAt *time.Time     `json:"archived_at,omitempty"`
+	Stats      TeamStatsOut   `json:"stats"`
+	Total      TeamStatsOut   `json:"total"`
+	Children   []*TeamNodeOut `json:"children"`
+}
+
+type TeamTreeOut struct {
+	Teams []*TeamNodeOut `json:"teams"`
+}
+
+type ArchiveTeamIn struct {
+	TeamName string `json:"team_name" validate:"required"`
+}
+
+type DeleteTeamIn struct {
+	TeamName string `json:"team_name" validate:"required"`
+	Force    bool   `json:"force"`
+}
+
+type DeleteTeamOut struct {
+	TeamName string `json:"team_name"`
+	OpenPRs  int    `json:"open_pull_requests"`
 }
 
 type CreateTeamOut struct {
-	Name    string    `json:"team_name"`
-	Members []UserDTO `json:"members"`
+	Name       string     `json:"team_name"`
+	ParentName string     `json:"parent_name,omitempty"`
+	Members    []UserDTO  `json:"members"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 type TeamWrapper struct {
@@ -69,3 +250,55 @@ type TeamWrapper struct {
 type DeactivateOut struct {
 	Status string `json:"deactivated"`
 }
+
+type IssueTokenIn struct {
+	Name   string `json:"name" validate:"required"`
+	Scope  string `json:"scope" validate:"required,oneof=admin user"`
+	UserID string `json:"user_id" validate:"required_if=Scope user"`
+}
+
+type TokenOut struct {
+	ID        string     `json:"token_id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	UserID    string     `json:"user_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IssueTokenOut struct {
+	Secret string   `json:"secret"`
+	Token  TokenOut `json:"token"`
+}
+
+type RevokeTokenIn struct {
+	TokenID string `json:"token_id" validate:"required"`
+}
+
+type TokenWrapper struct {
+	Token TokenOut `json:"token"`
+}
+
+type HealthOut struct {
+	Status string `json:"status"`
+}
+
+type HealthCheckOut struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type ReadinessOut struct {
+	Status string           `json:"status"`
+	Checks []HealthCheckOut `json:"checks"`
+}
+
+type VersionOut struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+// SetETag exposes the resource version as a strong entity tag.
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// IfMatchVersion returns the version required by the If-Match header.
+// It returns 0 when the header is absent or "*", meaning no precondition.
+// If-Match uses strong comparison (RFC 9110), so weak tags are rejected.
+func IfMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, fmt.Errorf("%w: weak entity tag %s", errInvalidIfMatch, header)
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidIfMatch, header)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%w: %s", errInvalidIfMatch, header)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,50 @@
+package healthhand
+
+import (
+	"net/http"
+	"pr-service/internal/buildinfo"
+	"pr-service/internal/handlers"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/handlers/mapper"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HealthHandler struct {
+	healthService HealthService
+}
+
+func NewHealthHandler(healthService HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// RegisterRoutes adds the probe routes. They are meant to be mounted outside
+// authentication and rate limiting.
+func (h *HealthHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/healthz", h.Liveness)
+	r.Get("/readyz", h.Readiness)
+	r.Get("/version", h.Version)
+}
+
+// Liveness only tells that the process serves HTTP; it does not touch the
+// database, so a database outage does not get the pod restarted.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondJSON(w, http.StatusOK, dto.HealthOut{Status: "ok"})
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	readiness := h.healthService.Readiness(r.Context())
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	handlers.RespondJSON(w, status, mapper.ReadinessToResponse(readiness))
+}
+
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondJSON(w, http.StatusOK, mapper.VersionToResponse(buildinfo.Get()))
+}
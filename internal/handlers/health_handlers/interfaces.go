@@ -0,0 +1,10 @@
+package healthhand
+
+import (
+	"context"
+	"pr-service/internal/domain"
+)
+
+type HealthService interface {
+	Readiness(ctx context.Context) domain.Readiness
+}
@@ -0,0 +1,18 @@
+package mapper
+
+import (
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+)
+
+func TokenToResponse(token domain.APIToken) dto.TokenOut {
+	return dto.TokenOut{
+		ID:        token.ID,
+		TenantID:  token.TenantID,
+		Name:      token.Name,
+		Scope:     string(token.Scope),
+		UserID:    token.UserID,
+		CreatedAt: token.CreatedAt,
+		RevokedAt: token.RevokedAt,
+	}
+}
@@ -0,0 +1,33 @@
+package mapper
+
+import (
+	"pr-service/internal/buildinfo"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+)
+
+func ReadinessToResponse(r domain.Readiness) dto.ReadinessOut {
+	out := dto.ReadinessOut{
+		Status: "ready",
+		Checks: make([]dto.HealthCheckOut, 0, len(r.Checks)),
+	}
+	if !r.Ready {
+		out.Status = "not_ready"
+	}
+
+	for _, c := range r.Checks {
+		out.Checks = append(out.Checks, dto.HealthCheckOut{Name: c.Name, OK: c.OK, Detail: c.Detail})
+	}
+
+	return out
+}
+
+func VersionToResponse(info buildinfo.Info) dto.VersionOut {
+	return dto.VersionOut{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildTime: info.BuildTime,
+		Modified:  info.Modified,
+		GoVersion: info.GoVersion,
+	}
+}
@@ -12,6 +12,7 @@ func PRToResponse(pr domain.PullRequest) dto.CreatePullRequestOut {
 		AuthorID:  pr.AuthorID,
 		Status:    dto.PRStatus(pr.Status),
 		Reviewers: pr.AssignedReviewers,
+		Version:   pr.Version,
 	}
 }
 
@@ -31,3 +32,39 @@ func PRFromRequest(req dto.CreatePullRequestIn) domain.PullRequest {
 		Status:   domain.PRStatusOpen,
 	}
 }
+
+func AssignmentToResponse(trace domain.AssignmentTrace) dto.AssignmentOut {
+	candidates := make([]dto.AssignmentCandidateOut, len(trace.Candidates))
+	for i, c := range trace.Candidates {
+		candidates[i] = dto.AssignmentCandidateOut{
+			UserID:         c.UserID,
+			Eligible:       c.IsEligible(),
+			ExcludedReason: string(c.Excluded),
+			Weight:         c.Weight,
+		}
+	}
+
+	return dto.AssignmentOut{
+		Kind:               string(trace.Kind),
+		Strategy:           trace.Strategy,
+		Seed:               trace.Seed,
+		ReplacedReviewerID: trace.ReplacedReviewerID,
+		Candidates:         candidates,
+		Picked:             trace.Picked,
+		FallbackTeams:      trace.FallbackTeams,
+		LeadID:             trace.LeadID,
+		CreatedAt:          trace.CreatedAt,
+	}
+}
+
+func AssignmentsToResponse(prID string, traces []domain.AssignmentTrace) dto.ExplainAssignmentOut {
+	assignments := make([]dto.AssignmentOut, len(traces))
+	for i, trace := range traces {
+		assignments[i] = AssignmentToResponse(trace)
+	}
+
+	return dto.ExplainAssignmentOut{
+		PullRequestID: prID,
+		Assignments:   assignments,
+	}
+}
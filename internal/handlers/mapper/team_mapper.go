@@ -7,14 +7,124 @@ import (
 
 func TeamToResponse(team domain.Team) dto.CreateTeamOut {
 	return dto.CreateTeamOut{
-		Name:    team.Name,
-		Members: UsersToDTO(team.Members, team.Name),
+		Name:       team.Name,
+		ParentName: team.ParentName,
+		Members:    UsersToDTO(team.Members, team.Name),
+		ArchivedAt: team.ArchivedAt,
 	}
 }
 
+// TeamFromRequest maps a new team. Its members are new or have no team, so
+// a missing role means a regular member.
 func TeamFromRequest(req dto.CreateTeamIn) domain.Team {
+	members := UsersFromDTO(req.Members)
+	for i := range members {
+		if members[i].Role == "" {
+			members[i].Role = domain.RoleMember
+		}
+	}
+
 	return domain.Team{
-		Name:    req.Name,
-		Members: UsersFromDTO(req.Members),
+		Name:       req.Name,
+		ParentName: req.ParentName,
+		Members:    members,
+	}
+}
+
+func TeamDiffToResponse(diff domain.TeamDiff) dto.SyncTeamOut {
+	return dto.SyncTeamOut{
+		Team:    TeamToResponse(diff.Team),
+		Created: diff.Created,
+		Added:   UsersToDTO(diff.Added, diff.Team.Name),
+		Updated: UsersToDTO(diff.Updated, diff.Team.Name),
+		Removed: UsersToDTO(diff.Removed, ""),
+	}
+}
+
+func ImportToResponse(result domain.ImportResult) dto.ImportOut {
+	resp := dto.ImportOut{
+		DryRun: result.DryRun,
+		Teams:  make([]dto.TeamImportOut, len(result.Teams)),
+	}
+	for i, t := range result.Teams {
+		if t.Changed() {
+			resp.Changed++
+		}
+		resp.Teams[i] = dto.TeamImportOut{
+			TeamName:      t.Diff.Team.Name,
+			Created:       t.Diff.Created,
+			ParentName:    t.Diff.Team.ParentName,
+			OldParentName: t.OldParent,
+			ParentChanged: t.ParentChanged(),
+			Added:         UsersToDTO(t.Diff.Added, t.Diff.Team.Name),
+			Updated:       UsersToDTO(t.Diff.Updated, t.Diff.Team.Name),
+			Removed:       UsersToDTO(t.Diff.Removed, ""),
+		}
+		if len(t.Reassigned) > 0 {
+			resp.Teams[i].ReassignedPRs = PRsToResponse(t.Reassigned)
+		}
+	}
+	return resp
+}
+
+func ImportIssuesToResponse(issues domain.ImportIssues) dto.ImportErrorOut {
+	out := make([]dto.ImportIssueOut, len(issues))
+	for i, issue := range issues {
+		out[i] = dto.ImportIssueOut{
+			Line:     issue.Line,
+			TeamName: issue.Team,
+			UserID:   issue.UserID,
+			Message:  issue.Message,
+		}
+	}
+	return dto.ImportErrorOut{Error: dto.ImportErrorDetail{
+		Code:    domain.ErrCodeInvalidImport,
+		Message: "import file has errors, nothing was applied",
+		Issues:  out,
+	}}
+}
+
+func TeamSummariesToResponse(teams []domain.TeamSummary) []dto.TeamSummaryOut {
+	result := make([]dto.TeamSummaryOut, len(teams))
+	for i, t := range teams {
+		result[i] = dto.TeamSummaryOut{
+			Name:        t.Name,
+			MemberCount: t.MemberCount,
+			ActiveCount: t.ActiveCount,
+			ArchivedAt:  t.ArchivedAt,
+		}
+	}
+	return result
+}
+
+func TeamTreeToResponse(nodes []*domain.TeamNode) []*dto.TeamNodeOut {
+	result := make([]*dto.TeamNodeOut, len(nodes))
+	for i, n := range nodes {
+		result[i] = &dto.TeamNodeOut{
+			Name:       n.Name,
+			ParentName: n.ParentName,
+			ArchivedAt: n.ArchivedAt,
+			Stats:      teamStatsToResponse(n.Own),
+			Total:      teamStatsToResponse(n.Total),
+			Children:   TeamTreeToResponse(n.Children),
+		}
+	}
+	return result
+}
+
+func teamStatsToResponse(s domain.TeamStats) dto.TeamStatsOut {
+	return dto.TeamStatsOut{
+		Members:       s.Members,
+		ActiveMembers: s.ActiveMembers,
+		OpenPRs:       s.OpenPRs,
+		MergedPRs:     s.MergedPRs,
+	}
+}
+
+func PageToResponse(page domain.Page, total int) dto.PaginationOut {
+	return dto.PaginationOut{
+		Limit:  page.Limit,
+		Offset: page.Offset,
+		Total:  total,
 	}
 }
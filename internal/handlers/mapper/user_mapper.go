@@ -11,15 +11,19 @@ func UserToDTO(user domain.User, team string) dto.UserDTO {
 		Username: user.Username,
 		IsActive: user.IsActive,
 		TeamName: team,
+		Role:     string(user.Role),
 	}
 }
 
+// UserFromDTO maps an incoming user. A missing role stays empty: existing
+// members keep theirs and new users become regular members.
 func UserFromDTO(userDTO dto.UserDTO) domain.User {
 	return domain.User{
 		ID:       userDTO.ID,
 		Username: userDTO.Username,
 		IsActive: userDTO.IsActive,
 		TeamName: userDTO.TeamName,
+		Role:     domain.Role(userDTO.Role),
 	}
 }
 
@@ -38,3 +42,12 @@ func UsersFromDTO(userDTOs []dto.UserDTO) []domain.User {
 	}
 	return result
 }
+
+// UsersWithTeamToDTO maps users that may belong to different teams.
+func UsersWithTeamToDTO(users []domain.User) []dto.UserDTO {
+	result := make([]dto.UserDTO, len(users))
+	for i, user := range users {
+		result[i] = UserToDTO(user, user.TeamName)
+	}
+	return result
+}
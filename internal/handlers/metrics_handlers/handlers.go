@@ -0,0 +1,37 @@
+package metricshand
+
+import (
+	"net/http"
+	"pr-service/internal/logger"
+	"pr-service/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type MetricsHandler struct {
+	exporter Exporter
+}
+
+func NewMetricsHandler(exporter Exporter) *MetricsHandler {
+	return &MetricsHandler{
+		exporter: exporter,
+	}
+}
+
+// RegisterRoutes adds /metrics. It has no authentication and the metrics
+// span all tenants, so it is meant for a separate, internal listener that
+// only the Prometheus scraper reaches.
+func (h *MetricsHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/metrics", h.Metrics)
+}
+
+// Metrics writes all metrics in the Prometheus text format. A failing
+// collector only drops its own metrics, so the scrape still succeeds.
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.exporter.WriteText(r.Context(), w); err != nil {
+		logger.FromContext(r.Context()).Warn().Err(err).Msg("metrics collection failed")
+	}
+}
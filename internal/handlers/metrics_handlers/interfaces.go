@@ -0,0 +1,10 @@
+package metricshand
+
+import (
+	"context"
+	"io"
+)
+
+type Exporter interface {
+	WriteText(ctx context.Context, w io.Writer) error
+}
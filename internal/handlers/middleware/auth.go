@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/logger"
+
+	"github.com/rs/zerolog"
+)
+
+// Authenticate requires an "Authorization: Bearer <token>" header and puts
+// the resolved identity into the request context. Requests without a valid
+// token get 401.
+func Authenticate(authn Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				unauthorized(w)
+				return
+			}
+
+			id, err := authn.Authenticate(r.Context(), token)
+			if err != nil {
+				if errors.Is(err, domain.ErrUnauthorized) {
+					unauthorized(w)
+					return
+				}
+				handlers.RespondInternalError(w, r, fmt.Errorf("authenticate request: %w", err))
+				return
+			}
+
+			logger.AddFields(r.Context(), func(c zerolog.Context) zerolog.Context {
+				c = c.Str("token_id", id.TokenID).Str("scope", string(id.Scope))
+				if id.UserID != "" {
+					c = c.Str("user_id", id.UserID)
+				}
+				return c
+			})
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), id)))
+		})
+	}
+}
+
+// RequireScope lets through callers whose token has the given scope. Admin
+// tokens pass every scope check.
+func RequireScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := auth.FromContext(r.Context())
+			if !ok {
+				unauthorized(w)
+				return
+			}
+			if !id.IsAdmin() && id.Scope != scope {
+				handlers.RespondError(w, http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pr-service"`)
+	handlers.RespondError(w, http.StatusUnauthorized, domain.ErrCodeUnauthorized, domain.ErrUnauthorized.Error())
+}
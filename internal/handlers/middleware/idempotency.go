@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/logger"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	maxIdempotentBody    = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with the body and sent
+// back on replay.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency makes mutating requests that carry an Idempotency-Key safe to
+// retry. The first request reserves the key with a fingerprint of caller,
+// method, path and body; its response is stored and replayed to retries within ttl. A retry
+// with a different fingerprint gets 422, a retry while the first request is
+// still running gets 409. Server errors, and responses that could not be
+// stored, release the key so the request can be retried for real. A
+// reservation left behind, e.g. by a crash, is taken over by a retry once
+// lockTimeout has passed. Bodies over 1 MiB are refused with 413, since they
+// are stored for the fingerprint.
+func Idempotency(store IdempotencyStore, ttl, lockTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLen {
+				handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "Idempotency-Key is too long")
+				return
+			}
+
+			// One byte past the limit tells a body that is too long from one
+			// that is exactly at it; a cut body must never reach the handler.
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "invalid request body")
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				handlers.RespondError(w, http.StatusRequestEntityTooLarge, domain.ErrCodeInvalidData, "request body is too large for Idempotency-Key")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			rec := domain.IdempotencyRecord{
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				ExpiresAt:   now.Add(ttl),
+				LockedUntil: now.Add(lockTimeout),
+			}
+
+			existing, reserved, err := store.Reserve(r.Context(), rec)
+			if err != nil {
+				logger.FromContext(r.Context()).Error().Err(err).Str("key", key).Msg("failed to reserve idempotency key")
+				handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+				return
+			}
+
+			if !reserved {
+				replay(w, rec, existing)
+				return
+			}
+
+			release := func() {
+				if err := store.Release(context.WithoutCancel(r.Context()), rec); err != nil {
+					logger.FromContext(r.Context()).Error().Err(err).Str("key", key).Msg("failed to release idempotency key")
+				}
+			}
+
+			defer func() {
+				if p := recover(); p != nil {
+					release()
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				release()
+				return
+			}
+
+			rec.StatusCode = recorder.status
+			rec.Body = recorder.body.Bytes()
+			rec.Headers = make(map[string]string, len(replayedHeaders))
+			for _, h := range replayedHeaders {
+				if v := recorder.Header().Get(h); v != "" {
+					rec.Headers[h] = v
+				}
+			}
+
+			if err := store.Complete(context.WithoutCancel(r.Context()), rec); err != nil {
+				logger.FromContext(r.Context()).Error().Err(err).Str("key", key).Msg("failed to store idempotent response")
+				release()
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, rec domain.IdempotencyRecord, existing *domain.IdempotencyRecord) {
+	if existing.Fingerprint != rec.Fingerprint {
+		handlers.RespondError(w, http.StatusUnprocessableEntity, domain.ErrCodeIdempotencyReuse, domain.ErrIdempotencyReuse.Error())
+		return
+	}
+
+	if !existing.IsCompleted() {
+		handlers.RespondError(w, http.StatusConflict, domain.ErrCodeIdempotencyBusy, domain.ErrIdempotencyBusy.Error())
+		return
+	}
+
+	for h, v := range existing.Headers {
+		w.Header().Set(h, v)
+	}
+	w.Header().Set(IdempotencyReplayedHeader, "true")
+	w.WriteHeader(existing.StatusCode)
+	_, _ = w.Write(existing.Body)
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	// A key reused by another caller must not replay someone else's
+	// response.
+	if id, ok := auth.FromContext(r.Context()); ok {
+		h.Write([]byte(id.TokenID))
+	}
+	h.Write([]byte{'\n'})
+	h.Write([]byte(r.Method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// responseRecorder passes the response through while keeping a copy.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
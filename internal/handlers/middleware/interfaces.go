@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/ratelimit"
+	"time"
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, rec domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, rec domain.IdempotencyRecord) error
+	Release(ctx context.Context, rec domain.IdempotencyRecord) error
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (auth.Identity, error)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Decision, error)
+}
+
+type HTTPMetrics interface {
+	ObserveRequest(method, route string, status int, elapsed time.Duration)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"pr-service/internal/logger"
+	"pr-service/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestLogger puts a logger with the request ID and trace ID into the
+// request context and logs every request when it completes, with its route,
+// status, size and latency. Authenticate and ResolveTenant add the caller to
+// the same logger. It must run after middleware.RequestID and Trace.
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		fields := logger.FromContext(r.Context()).With().
+			Str("request_id", middleware.GetReqID(r.Context()))
+		if traceID := tracing.TraceID(r.Context()); traceID != "" {
+			fields = fields.Str("trace_id", traceID)
+		}
+		ctx := logger.WithContext(r.Context(), fields.Logger())
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		l := logger.FromContext(ctx)
+		event := l.Info()
+		if status >= http.StatusInternalServerError {
+			event = l.Error()
+		}
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		event.
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("route", route).
+			Int("status", status).
+			Int("bytes", ww.BytesWritten()).
+			Dur("latency_ms", time.Since(start)).
+			Str("remote_addr", r.RemoteAddr).
+			Msg("request")
+	})
+}
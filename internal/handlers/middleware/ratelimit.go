@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/logger"
+	"pr-service/internal/ratelimit"
+)
+
+// RateLimit counts requests per client in token buckets: per API token when
+// the request is authenticated, per client IP otherwise. Every response gets
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset; rejected
+// ones get 429 with Retry-After. When the limiter fails the request is let
+// through.
+func RateLimit(limiter RateLimiter, policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) (ratelimit.Limit, string) {
+		limit, route := policy.For(r.URL.Path)
+		return limit, clientKey(r) + " " + route
+	})
+}
+
+// RateLimitByIP counts all requests of a client IP in one bucket. It goes
+// before Authenticate, so that clients flooding the service with missing or
+// bad credentials are cut off before their tokens are looked up.
+func RateLimitByIP(limiter RateLimiter, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) (ratelimit.Limit, string) {
+		return limit, ipKey(r) + " pre-auth"
+	})
+}
+
+func rateLimit(limiter RateLimiter, bucket func(r *http.Request) (ratelimit.Limit, string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, key := bucket(r)
+			if limit.IsZero() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			d, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				logger.FromContext(r.Context()).Error().Err(err).Msg("rate limit")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(d.Reset)))
+
+			if !d.Allowed {
+				h.Set("Retry-After", strconv.Itoa(max(1, ratelimit.Seconds(d.RetryAfter))))
+				handlers.RespondError(w, http.StatusTooManyRequests, domain.ErrCodeRateLimited, domain.ErrRateLimited.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok {
+		return "token:" + id.TokenID
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
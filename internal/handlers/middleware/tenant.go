@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/logger"
+	"pr-service/internal/tenant"
+
+	"github.com/rs/zerolog"
+)
+
+// TenantHeader names the tenant for callers that are not bound to one.
+const TenantHeader = "X-Tenant-ID"
+
+// ResolveTenant puts the tenant of the request into the context. It must run
+// after Authenticate. Credentials bound to a tenant always act in it, and a
+// TenantHeader naming another tenant is rejected with 403; the bootstrap
+// token picks the tenant with the header and falls back to the default one.
+func ResolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested := r.Header.Get(TenantHeader)
+		if requested != "" && !tenant.IsValid(requested) {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, domain.ErrInvalidTenant.Error())
+			return
+		}
+
+		id, ok := auth.FromContext(r.Context())
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		tenantID := id.TenantID
+		switch {
+		case tenantID == "" && requested != "":
+			tenantID = requested
+		case tenantID == "":
+			tenantID = tenant.Default
+		case requested != "" && requested != tenantID:
+			handlers.RespondError(w, http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrTenantMismatch.Error())
+			return
+		}
+
+		logger.AddFields(r.Context(), func(c zerolog.Context) zerolog.Context {
+			return c.Str("tenant", tenantID)
+		})
+
+		next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"pr-service/internal/domain"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var errInvalidPage = errors.New("limit must be between 1 and 200 and offset must not be negative")
+
+// ParsePage reads the limit and offset query parameters.
+func ParsePage(r *http.Request) (domain.Page, error) {
+	page := domain.Page{Limit: DefaultPageLimit}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return domain.Page{}, errInvalidPage
+		}
+		page.Limit = limit
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return domain.Page{}, errInvalidPage
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
@@ -24,8 +24,10 @@ func NewPRHandler(prService PRService) *PRHandler {
 
 func (h *PRHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/pullRequest/create", h.CreatePullRequest)
+	r.Get("/pullRequest/get", h.GetPullRequest)
 	r.Post("/pullRequest/merge", h.MergePullRequest)
 	r.Post("/pullRequest/reassign", h.ReassignReviewer)
+	r.Get("/pullRequest/explainAssignment", h.ExplainAssignment)
 	r.Get("/stats", h.GetStats)
 }
 
@@ -36,9 +38,10 @@ func (h *PRHandler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pullRequestCreate := domain.PullRequestCreate{
-		ID:       req.ID,
-		Name:     req.Name,
-		AuthorID: req.AuthorID,
+		ID:          req.ID,
+		Name:        req.Name,
+		AuthorID:    req.AuthorID,
+		RequestLead: req.RequestLead,
 	}
 
 	pr, err := h.prService.Create(r.Context(), pullRequestCreate)
@@ -54,7 +57,17 @@ func (h *PRHandler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		if errors.Is(err, domain.ErrForbidden) {
+			handlers.RespondError(w, http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error())
+			return
+		}
+
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
@@ -62,23 +75,65 @@ func (h *PRHandler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 		PR: mapper.PRToResponse(pr),
 	}
 
+	handlers.SetETag(w, pr.Version)
 	handlers.RespondJSON(w, http.StatusCreated, response)
 }
 
+func (h *PRHandler) GetPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+
+	if err := handlers.Validate.Var(prID, "required"); err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.prService.Get(r.Context(), prID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	response := dto.PullRequestWrapper{
+		PR: mapper.PRToResponse(*pr),
+	}
+
+	handlers.SetETag(w, pr.Version)
+	handlers.RespondJSON(w, http.StatusOK, response)
+}
+
 func (h *PRHandler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
 	req, ok := handlers.DecodeAndValidate[dto.MergePullRequest](w, r)
 	if !ok {
 		return
 	}
 
-	pr, err := h.prService.Merge(r.Context(), req.ID)
+	version, err := handlers.IfMatchVersion(r)
+	if err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	pr, err := h.prService.Merge(r.Context(), req.ID, version)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handlers.RespondError(w, http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			handlers.RespondError(w, http.StatusPreconditionFailed, domain.ErrCodeVersionMismatch, domain.ErrVersionMismatch.Error())
+			return
+		}
 
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
@@ -86,6 +141,7 @@ func (h *PRHandler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
 		PR: mapper.PRToResponse(*pr),
 	}
 
+	handlers.SetETag(w, pr.Version)
 	handlers.RespondJSON(w, http.StatusOK, response)
 }
 
@@ -95,25 +151,43 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.prService.Reassign(r.Context(), req.PullRequestID, req.OldReviewerID)
+	version, err := handlers.IfMatchVersion(r)
+	if err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	pr, err := h.prService.Reassign(r.Context(), req.PullRequestID, req.OldReviewerID, version)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			handlers.RespondError(w, http.StatusForbidden, domain.ErrCodeForbidden, domain.ErrForbidden.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			handlers.RespondError(w, http.StatusPreconditionFailed, domain.ErrCodeVersionMismatch, domain.ErrVersionMismatch.Error())
+			return
+		}
 		if errors.Is(err, domain.ErrPRMerged) {
-			handlers.RespondError(w, http.StatusConflict, domain.ErrCodePRMerged, err.Error())
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodePRMerged, domain.ErrPRMerged.Error())
 			return
 		}
 		if errors.Is(err, domain.ErrNotAssigned) {
-			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNotAssigned, err.Error())
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNotAssigned, domain.ErrNotAssigned.Error())
 			return
 		}
 		if errors.Is(err, domain.ErrNoCandidate) {
-			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, err.Error())
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
 			return
 		}
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		if errors.Is(err, domain.ErrReviewerConflict) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeReviewerConflict, domain.ErrReviewerConflict.Error())
+			return
+		}
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
@@ -121,22 +195,41 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		PR: mapper.PRToResponse(*pr),
 	}
 
+	handlers.SetETag(w, pr.Version)
 	handlers.RespondJSON(w, http.StatusOK, response)
 }
 
-func (h *PRHandler) GetStats(w http.ResponseWriter, r *http.Request) {
-	prs, err := h.prService.GetAllPRs(r.Context())
+func (h *PRHandler) ExplainAssignment(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+
+	if err := handlers.Validate.Var(prID, "required"); err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "pull_request_id is required")
+		return
+	}
+
+	traces, err := h.prService.ExplainAssignment(r.Context(), prID)
 	if err != nil {
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
-	prResponses := mapper.PRsToResponse(prs)
+	handlers.RespondJSON(w, http.StatusOK, mapper.AssignmentsToResponse(prID, traces))
+}
 
-	response := map[string]interface{}{
-		"total_pull_requests": len(prs),
-		"pull_requests":       prResponses,
+func (h *PRHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	prs, err := h.prService.GetAllPRs(r.Context())
+	if err != nil {
+		handlers.RespondInternalError(w, r, err)
+		return
 	}
 
-	handlers.RespondJSON(w, http.StatusOK, response)
+	handlers.RespondJSON(w, http.StatusOK, dto.StatsOut{
+		Total:        len(prs),
+		PullRequests: mapper.PRsToResponse(prs),
+	})
 }
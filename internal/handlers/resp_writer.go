@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"pr-service/internal/domain"
+	"pr-service/internal/logger"
 )
 
 func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -17,6 +18,13 @@ func RespondError(w http.ResponseWriter, status int, code string, msg string) {
 	RespondJSON(w, status, errResp)
 }
 
+// RespondInternalError logs err with the request logger, so that it can be
+// found by the request ID, and responds 500 without the details.
+func RespondInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.FromContext(r.Context()).Error().Err(err).Msg("internal error")
+	RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+}
+
 func DecodeAndValidate[T any](w http.ResponseWriter, r *http.Request) (T, bool) {
 	var data T
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
@@ -1,16 +1,23 @@
 package teamhand
 
 import (
+	"bytes"
 	"errors"
 	"net/http"
 	"pr-service/internal/domain"
 	"pr-service/internal/handlers"
 	"pr-service/internal/handlers/dto"
 	"pr-service/internal/handlers/mapper"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/orgchart"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// maxImportSize limits the body of /team/import.
+const maxImportSize = 10 << 20
+
 type TeamHandler struct {
 	teamService TeamService
 }
@@ -22,9 +29,25 @@ func NewTeamHandler(teamService TeamService) *TeamHandler {
 }
 
 func (h *TeamHandler) RegisterRoutes(r chi.Router) {
-	r.Post("/team/add", h.CreateTeam)
 	r.Get("/team/get", h.GetTeam)
-	r.Post("/deactivate", h.DeactivateTeam)
+	r.Get("/team/list", h.ListTeams)
+	r.Get("/team/tree", h.GetTree)
+	r.Get("/team/export", h.ExportTeams)
+
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.RequireScope(domain.ScopeAdmin))
+
+		r.Post("/team/add", h.CreateTeam)
+		r.Post("/team/addMembers", h.AddMembers)
+		r.Post("/team/removeMember", h.RemoveMember)
+		r.Post("/team/setRole", h.SetRole)
+		r.Post("/team/sync", h.SyncTeam)
+		r.Post("/team/setParent", h.SetParent)
+		r.Post("/team/import", h.ImportTeams)
+		r.Post("/team/archive", h.ArchiveTeam)
+		r.Post("/team/delete", h.DeleteTeam)
+		r.Post("/deactivate", h.DeactivateTeam)
+	})
 }
 
 func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
@@ -41,8 +64,20 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamExists, err.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "parent team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUserInOtherTeam) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeUserInOtherTeam, err.Error())
+			return
+		}
 
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
@@ -67,13 +102,392 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
 			return
 		}
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
 	handlers.RespondJSON(w, http.StatusOK, mapper.TeamToResponse(team))
 }
 
+func (h *TeamHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	page, err := handlers.ParsePage(r)
+	if err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	includeArchived := false
+	if v := r.URL.Query().Get("include_archived"); v != "" {
+		includeArchived, err = strconv.ParseBool(v)
+		if err != nil {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "include_archived must be a boolean")
+			return
+		}
+	}
+
+	teams, total, err := h.teamService.List(r.Context(), includeArchived, page)
+	if err != nil {
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TeamListOut{
+		Teams:      mapper.TeamSummariesToResponse(teams),
+		Pagination: mapper.PageToResponse(page, total),
+	})
+}
+
+func (h *TeamHandler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.AddTeamMembersIn](w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.AddMembers(r.Context(), req.TeamName, mapper.UsersFromDTO(req.Members))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUserInOtherTeam) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeUserInOtherTeam, err.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TeamWrapper{Team: mapper.TeamToResponse(team)})
+}
+
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.RemoveTeamMemberIn](w, r)
+	if !ok {
+		return
+	}
+
+	user, reassigned, err := h.teamService.RemoveMember(r.Context(), req.TeamName, req.UserID, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotTeamMember) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotTeamMember.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	resp := dto.UserWrapper{
+		User: mapper.UserToDTO(*user, user.TeamName),
+	}
+	if len(reassigned) > 0 {
+		resp.ReassignedPRs = mapper.PRsToResponse(reassigned)
+	}
+	handlers.RespondJSON(w, http.StatusOK, resp)
+}
+
+func (h *TeamHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.SetTeamRoleIn](w, r)
+	if !ok {
+		return
+	}
+
+	user, reassigned, err := h.teamService.SetRole(r.Context(), req.TeamName, req.UserID, domain.Role(req.Role), req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRole) {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, domain.ErrInvalidRole.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrNotTeamMember) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotTeamMember.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	resp := dto.UserWrapper{
+		User: mapper.UserToDTO(*user, user.TeamName),
+	}
+	if len(reassigned) > 0 {
+		resp.ReassignedPRs = mapper.PRsToResponse(reassigned)
+	}
+	handlers.RespondJSON(w, http.StatusOK, resp)
+}
+
+func (h *TeamHandler) SyncTeam(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.SyncTeamIn](w, r)
+	if !ok {
+		return
+	}
+
+	team := domain.Team{
+		Name:    req.Name,
+		Members: mapper.UsersFromDTO(req.Members),
+	}
+
+	diff, reassigned, err := h.teamService.Sync(r.Context(), team, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrTeamAlreadyExists) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamExists, domain.ErrTeamAlreadyExists.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	resp := mapper.TeamDiffToResponse(diff)
+	if len(reassigned) > 0 {
+		resp.ReassignedPRs = mapper.PRsToResponse(reassigned)
+	}
+
+	status := http.StatusOK
+	if diff.Created {
+		status = http.StatusCreated
+	}
+	handlers.RespondJSON(w, status, resp)
+}
+
+func (h *TeamHandler) SetParent(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.SetTeamParentIn](w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.SetParent(r.Context(), req.TeamName, req.ParentName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamCycle) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamCycle, domain.ErrTeamCycle.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TeamWrapper{Team: mapper.TeamToResponse(team)})
+}
+
+// ImportTeams applies a CSV or YAML file of teams and members. The format
+// comes from the format query parameter or the Content-Type.
+func (h *TeamHandler) ImportTeams(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = orgchart.FormatOf(r.Header.Get("Content-Type"))
+	}
+	if format != orgchart.CSV && format != orgchart.YAML {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "format must be csv or yaml")
+		return
+	}
+
+	var opts domain.ImportOptions
+	for name, dst := range map[string]*bool{"dry_run": &opts.DryRun, "reassign_reviews": &opts.ReassignReviews} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, name+" must be a boolean")
+			return
+		}
+		*dst = b
+	}
+
+	teams, err := orgchart.Decode(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var issues domain.ImportIssues
+		if errors.As(err, &issues) {
+			handlers.RespondJSON(w, http.StatusBadRequest, mapper.ImportIssuesToResponse(issues))
+			return
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			handlers.RespondError(w, http.StatusRequestEntityTooLarge, domain.ErrCodeInvalidData, "import file is too large")
+			return
+		}
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "invalid request body")
+		return
+	}
+
+	result, err := h.teamService.Import(r.Context(), teams, opts)
+	if err != nil {
+		var issues domain.ImportIssues
+		if errors.As(err, &issues) {
+			handlers.RespondJSON(w, http.StatusBadRequest, mapper.ImportIssuesToResponse(issues))
+			return
+		}
+		if errors.Is(err, domain.ErrTeamAlreadyExists) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamExists, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, err.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, mapper.ImportToResponse(result))
+}
+
+// ExportTeams writes every team that is not archived in the format that
+// ImportTeams accepts, CSV unless format or Accept asks for YAML.
+func (h *TeamHandler) ExportTeams(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = orgchart.FormatOf(r.Header.Get("Accept"))
+	}
+	if format == "" {
+		format = orgchart.CSV
+	}
+	if format != orgchart.CSV && format != orgchart.YAML {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "format must be csv or yaml")
+		return
+	}
+
+	teams, err := h.teamService.Export(r.Context())
+	if err != nil {
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := orgchart.Encode(format, &buf, teams); err != nil {
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", orgchart.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="teams.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (h *TeamHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	includeArchived := false
+	if v := r.URL.Query().Get("include_archived"); v != "" {
+		var err error
+		includeArchived, err = strconv.ParseBool(v)
+		if err != nil {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "include_archived must be a boolean")
+			return
+		}
+	}
+
+	nodes, err := h.teamService.Tree(r.Context(), r.URL.Query().Get("team_name"), includeArchived)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TeamTreeOut{Teams: mapper.TeamTreeToResponse(nodes)})
+}
+
+func (h *TeamHandler) ArchiveTeam(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.ArchiveTeamIn](w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.Archive(r.Context(), req.TeamName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.TeamWrapper{Team: mapper.TeamToResponse(team)})
+}
+
+func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.DeleteTeamIn](w, r)
+	if !ok {
+		return
+	}
+
+	openPRs, err := h.teamService.Delete(r.Context(), req.TeamName, req.Force)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrTeamHasOpenPRs) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamHasOpenPRs, domain.ErrTeamHasOpenPRs.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.DeleteTeamOut{TeamName: req.TeamName, OpenPRs: openPRs})
+}
+
 func (h *TeamHandler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("team_name")
 
@@ -84,7 +498,7 @@ func (h *TeamHandler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
 
 	err := h.teamService.DeactivateTeam(r.Context(), name)
 	if err != nil {
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
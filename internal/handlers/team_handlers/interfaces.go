@@ -9,4 +9,15 @@ type TeamService interface {
 	Create(ctx context.Context, team domain.Team) error
 	Get(ctx context.Context, name string) (domain.Team, error)
 	DeactivateTeam(ctx context.Context, teamName string) error
+	Sync(ctx context.Context, team domain.Team, reassignReviews bool) (domain.TeamDiff, []domain.PullRequest, error)
+	Archive(ctx context.Context, name string) (domain.Team, error)
+	Delete(ctx context.Context, name string, force bool) (int, error)
+	List(ctx context.Context, includeArchived bool, page domain.Page) ([]domain.TeamSummary, int, error)
+	AddMembers(ctx context.Context, teamName string, members []domain.User) (domain.Team, error)
+	RemoveMember(ctx context.Context, teamName, userID string, reassignReviews bool) (*domain.User, []domain.PullRequest, error)
+	SetRole(ctx context.Context, teamName, userID string, role domain.Role, reassignReviews bool) (*domain.User, []domain.PullRequest, error)
+	SetParent(ctx context.Context, name, parent string) (domain.Team, error)
+	Tree(ctx context.Context, root string, includeArchived bool) ([]*domain.TeamNode, error)
+	Import(ctx context.Context, teams []domain.ImportTeam, opts domain.ImportOptions) (domain.ImportResult, error)
+	Export(ctx context.Context) ([]domain.Team, error)
 }
@@ -7,6 +7,8 @@ import (
 	"pr-service/internal/handlers"
 	"pr-service/internal/handlers/dto"
 	"pr-service/internal/handlers/mapper"
+	appmw "pr-service/internal/handlers/middleware"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -22,8 +24,17 @@ func NewUserHandler(userService UserService) *UserHandler {
 }
 
 func (h *UserHandler) RegisterRoutes(r chi.Router) {
-	r.Post("/users/setIsActive", h.SetUserActive)
 	r.Get("/users/getReview", h.GetUserReviews)
+	r.Get("/users/get", h.GetUser)
+	r.Get("/users/search", h.SearchUsers)
+
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.RequireScope(domain.ScopeAdmin))
+
+		r.Post("/users/setIsActive", h.SetUserActive)
+		r.Post("/users/move", h.MoveUser)
+		r.Post("/users/update", h.UpdateUser)
+	})
 }
 
 func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
@@ -32,23 +43,153 @@ func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.SetActive(r.Context(), req.UserID, req.IsActive)
+	var (
+		user       *domain.User
+		reassigned []domain.PullRequest
+		err        error
+	)
+
+	if !req.IsActive && req.ReassignReviews {
+		user, reassigned, err = h.userService.DeactivateAndReassign(r.Context(), req.UserID)
+	} else {
+		user, err = h.userService.SetActive(r.Context(), req.UserID, req.IsActive)
+	}
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
 			return
 		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
 
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
 	resp := dto.UserWrapper{
 		User: mapper.UserToDTO(*user, user.TeamName),
 	}
+	if len(reassigned) > 0 {
+		resp.ReassignedPRs = mapper.PRsToResponse(reassigned)
+	}
 	handlers.RespondJSON(w, http.StatusOK, resp)
 }
 
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "user_id is required")
+		return
+	}
+
+	user, err := h.userService.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.UserWrapper{User: mapper.UserToDTO(*user, user.TeamName)})
+}
+
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := handlers.ParsePage(r)
+	if err != nil {
+		handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	filter := domain.UserFilter{
+		UsernamePrefix: q.Get("username_prefix"),
+		TeamName:       q.Get("team_name"),
+	}
+
+	if v := q.Get("is_active"); v != "" {
+		isActive, err := strconv.ParseBool(v)
+		if err != nil {
+			handlers.RespondError(w, http.StatusBadRequest, domain.ErrCodeInvalidData, "is_active must be a boolean")
+			return
+		}
+		filter.IsActive = &isActive
+	}
+
+	users, total, err := h.userService.Search(r.Context(), filter, page)
+	if err != nil {
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.UserSearchOut{
+		Users:      mapper.UsersWithTeamToDTO(users),
+		Pagination: mapper.PageToResponse(page, total),
+	})
+}
+
+func (h *UserHandler) MoveUser(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.MoveUserIn](w, r)
+	if !ok {
+		return
+	}
+
+	user, reassigned, err := h.userService.Move(r.Context(), req.UserID, req.TeamName, req.ReassignReviews)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNoCandidate) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeNoCandidate, domain.ErrNoCandidate.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrTeamArchived) {
+			handlers.RespondError(w, http.StatusConflict, domain.ErrCodeTeamArchived, domain.ErrTeamArchived.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	resp := dto.UserWrapper{
+		User: mapper.UserToDTO(*user, user.TeamName),
+	}
+	if len(reassigned) > 0 {
+		resp.ReassignedPRs = mapper.PRsToResponse(reassigned)
+	}
+	handlers.RespondJSON(w, http.StatusOK, resp)
+}
+
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	req, ok := handlers.DecodeAndValidate[dto.UpdateUserIn](w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.UpdateUsername(r.Context(), req.UserID, req.Username)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			handlers.RespondError(w, http.StatusNotFound, domain.ErrCodeNotFound, domain.ErrNotFound.Error())
+			return
+		}
+
+		handlers.RespondInternalError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, dto.UserWrapper{User: mapper.UserToDTO(*user, user.TeamName)})
+}
+
 func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
@@ -63,7 +204,7 @@ func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		handlers.RespondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		handlers.RespondInternalError(w, r, err)
 		return
 	}
 
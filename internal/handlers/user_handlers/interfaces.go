@@ -7,5 +7,10 @@ import (
 
 type UserService interface {
 	SetActive(ctx context.Context, userID string, isActive bool) (*domain.User, error)
+	DeactivateAndReassign(ctx context.Context, userID string) (*domain.User, []domain.PullRequest, error)
 	GetByReviewer(ctx context.Context, reviewerID string) ([]domain.PullRequest, error)
+	Move(ctx context.Context, userID, teamName string, reassignReviews bool) (*domain.User, []domain.PullRequest, error)
+	UpdateUsername(ctx context.Context, userID, username string) (*domain.User, error)
+	Get(ctx context.Context, userID string) (*domain.User, error)
+	Search(ctx context.Context, filter domain.UserFilter, page domain.Page) ([]domain.User, int, error)
 }
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"io"
 	"os"
 	"time"
 
@@ -8,9 +10,23 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func Init(level string) {
+// Output formats accepted by Init.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Init configures the global logger. format is "json" (one object per line,
+// for the log pipeline) or "console" (human-readable, for local runs);
+// anything else falls back to JSON.
+func Init(level, format string) {
 	zerolog.TimeFieldFormat = time.RFC3339
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+
+	var out io.Writer = os.Stdout
+	if format == FormatConsole {
+		out = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	}
+	log.Logger = zerolog.New(out).With().Timestamp().Logger()
 
 	switch level {
 	case "debug":
@@ -25,3 +41,30 @@ func Init(level string) {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 }
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying l. The request logging
+// middleware stores a logger with the request ID here.
+func WithContext(ctx context.Context, l zerolog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &l)
+}
+
+// FromContext returns the logger carried by ctx, or the global logger when
+// ctx has none. Use it wherever a ctx is at hand, so that log lines can be
+// matched to the request that caused them.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zerolog.Logger); ok {
+		return l
+	}
+	return &log.Logger
+}
+
+// AddFields adds fields to the logger carried by ctx, for everything logged
+// with it from now on, including lines already set up by outer middleware.
+// It does nothing when ctx has no logger of its own.
+func AddFields(ctx context.Context, fields func(c zerolog.Context) zerolog.Context) {
+	if l, ok := ctx.Value(ctxKey{}).(*zerolog.Logger); ok {
+		l.UpdateContext(fields)
+	}
+}
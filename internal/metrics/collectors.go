@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pr-service/internal/domain"
+)
+
+// StatsReader is implemented by *sql.DB and *sqlx.DB.
+type StatsReader interface {
+	Stats() sql.DBStats
+}
+
+// DBStats exports the connection pool statistics of db.
+func DBStats(db StatsReader) Collector {
+	return CollectorFunc(func(context.Context) ([]Family, error) {
+		s := db.Stats()
+
+		gauge := func(name, help string, v float64) Family {
+			return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Value: v}}}
+		}
+		counter := func(name, help string, v float64) Family {
+			return Family{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Value: v}}}
+		}
+
+		return []Family{
+			gauge("pr_service_db_max_open_connections", "Maximum number of open connections to the database.", float64(s.MaxOpenConnections)),
+			gauge("pr_service_db_open_connections", "Number of established connections, in use and idle.", float64(s.OpenConnections)),
+			gauge("pr_service_db_in_use_connections", "Number of connections currently in use.", float64(s.InUse)),
+			gauge("pr_service_db_idle_connections", "Number of idle connections.", float64(s.Idle)),
+			counter("pr_service_db_wait_count_total", "Total number of connections waited for.", float64(s.WaitCount)),
+			counter("pr_service_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", s.WaitDuration.Seconds()),
+			counter("pr_service_db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.", float64(s.MaxIdleClosed)),
+			counter("pr_service_db_max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime.", float64(s.MaxIdleTimeClosed)),
+			counter("pr_service_db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.", float64(s.MaxLifetimeClosed)),
+		}, nil
+	})
+}
+
+// ReviewLoadReader counts open review assignments per team across all
+// tenants.
+type ReviewLoadReader interface {
+	CountOpenReviewsByTeam(ctx context.Context) ([]domain.TeamReviewLoad, error)
+}
+
+// OpenReviews exports the number of open review assignments per team. It
+// queries the database on every scrape.
+func OpenReviews(r ReviewLoadReader) Collector {
+	return CollectorFunc(func(ctx context.Context) ([]Family, error) {
+		loads, err := r.CountOpenReviewsByTeam(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("collect open reviews: %w", err)
+		}
+
+		f := Family{
+			Name: "pr_service_open_reviews",
+			Help: "Number of reviews assigned on open pull requests, by reviewer's team.",
+			Type: TypeGauge,
+		}
+		for _, l := range loads {
+			f.Samples = append(f.Samples, Sample{
+				Labels: []Label{{Name: "tenant", Value: l.TenantID}, {Name: "team", Value: l.TeamName}},
+				Value:  float64(l.OpenReviews),
+			})
+		}
+		return []Family{f}, nil
+	})
+}
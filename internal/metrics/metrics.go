@@ -0,0 +1,272 @@
+// Package metrics keeps counters, gauges and histograms in memory and writes
+// them in the Prometheus text exposition format (version 0.0.4). It covers
+// only what the service needs, so the Prometheus client library is not
+// required.
+package metrics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metric types as written on the # TYPE line.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefBuckets are the default latency buckets in seconds, the same as in the
+// Prometheus client libraries.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one line of a family. Suffix is appended to the family name,
+// e.g. "_bucket" for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a metric with all its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector produces families at scrape time.
+type Collector interface {
+	Collect(ctx context.Context) ([]Family, error)
+}
+
+// CollectorFunc adapts a function to Collector, for values that are read
+// from elsewhere on every scrape.
+type CollectorFunc func(ctx context.Context) ([]Family, error)
+
+func (f CollectorFunc) Collect(ctx context.Context) ([]Family, error) {
+	return f(ctx)
+}
+
+// vec holds one value per combination of label values.
+type vec[T any] struct {
+	name   string
+	help   string
+	labels []string
+
+	mu      sync.Mutex
+	entries map[string]*entry[T]
+}
+
+type entry[T any] struct {
+	values []string
+	v      T
+}
+
+func newVec[T any](name, help string, labels []string) vec[T] {
+	return vec[T]{name: name, help: help, labels: labels, entries: make(map[string]*entry[T])}
+}
+
+// with returns the entry for the label values, creating it with init. It
+// must be called with mu held. Missing values are left empty, extra ones
+// are dropped.
+func (v *vec[T]) with(values []string, init func() T) *entry[T] {
+	values = fitValues(values, len(v.labels))
+	key := strings.Join(values, "\xff")
+
+	e, ok := v.entries[key]
+	if !ok {
+		e = &entry[T]{values: values, v: init()}
+		v.entries[key] = e
+	}
+	return e
+}
+
+// sorted returns the entries ordered by label values, so that the output
+// is stable between scrapes. It must be called with mu held.
+func (v *vec[T]) sorted() []*entry[T] {
+	out := make([]*entry[T], 0, len(v.entries))
+	for _, e := range v.entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+func (v *vec[T]) labelPairs(values []string) []Label {
+	pairs := make([]Label, len(v.labels))
+	for i, name := range v.labels {
+		pairs[i] = Label{Name: name, Value: values[i]}
+	}
+	return pairs
+}
+
+func fitValues(values []string, n int) []string {
+	out := make([]string, n)
+	copy(out, values)
+	return out
+}
+
+// CounterVec is a family of counters partitioned by labels.
+type CounterVec struct {
+	vec[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: newVec[float64](name, help, labels)}
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increases the counter; negative deltas are ignored because counters
+// only go up.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.with(values, zero).v += delta
+}
+
+func (c *CounterVec) Collect(context.Context) ([]Family, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	for _, e := range c.sorted() {
+		f.Samples = append(f.Samples, Sample{Labels: c.labelPairs(e.values), Value: e.v})
+	}
+	return []Family{f}, nil
+}
+
+// GaugeVec is a family of gauges partitioned by labels.
+type GaugeVec struct {
+	vec[float64]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: newVec[float64](name, help, labels)}
+}
+
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.with(values, zero).v = value
+}
+
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.with(values, zero).v += delta
+}
+
+func (g *GaugeVec) Collect(context.Context) ([]Family, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f := Family{Name: g.name, Help: g.help, Type: TypeGauge}
+	for _, e := range g.sorted() {
+		f.Samples = append(f.Samples, Sample{Labels: g.labelPairs(e.values), Value: e.v})
+	}
+	return []Family{f}, nil
+}
+
+// HistogramVec is a family of histograms partitioned by labels.
+type HistogramVec struct {
+	vec[*histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	// counts[i] is the number of observations in bucket i alone; they are
+	// made cumulative when written.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram with the given upper bounds, DefBuckets
+// when none are given. The +Inf bucket is always added.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if !math.IsInf(b, +1) {
+			bounds = append(bounds, b)
+		}
+	}
+	sort.Float64s(bounds)
+
+	return &HistogramVec{vec: newVec[*histogram](name, help, labels), buckets: bounds}
+}
+
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist := h.with(values, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	}).v
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.count++
+	hist.sum += value
+}
+
+func (h *HistogramVec) Collect(context.Context) ([]Family, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	for _, e := range h.sorted() {
+		labels := h.labelPairs(e.values)
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += e.v.counts[i]
+			f.Samples = append(f.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: withLabel(labels, "le", formatFloat(bound)),
+				Value:  float64(cumulative),
+			})
+		}
+		f.Samples = append(f.Samples,
+			Sample{Suffix: "_bucket", Labels: withLabel(labels, "le", "+Inf"), Value: float64(e.v.count)},
+			Sample{Suffix: "_sum", Labels: labels, Value: e.v.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(e.v.count)},
+		)
+	}
+	return []Family{f}, nil
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	out := make([]Label, len(labels), len(labels)+1)
+	copy(out, labels)
+	return append(out, Label{Name: name, Value: value})
+}
+
+func zero() float64 {
+	return 0
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"pr-service/internal/tenant"
+)
+
+// Recorder holds the metrics the service updates as it works: HTTP traffic
+// and the business events of the PR service.
+type Recorder struct {
+	httpRequests *CounterVec
+	httpDuration *HistogramVec
+
+	prsCreated    *CounterVec
+	prsMerged     *CounterVec
+	reassignments *CounterVec
+	noCandidate   *CounterVec
+}
+
+// NewRecorder creates the metrics and registers them in reg.
+func NewRecorder(reg *Registry) *Recorder {
+	rec := &Recorder{
+		httpRequests: NewCounterVec("pr_service_http_requests_total",
+			"Total number of HTTP requests.", "method", "route", "status"),
+		httpDuration: NewHistogramVec("pr_service_http_request_duration_seconds",
+			"HTTP request latency.", DefBuckets, "method", "route"),
+		prsCreated: NewCounterVec("pr_service_pull_requests_created_total",
+			"Total number of pull requests created.", "tenant"),
+		prsMerged: NewCounterVec("pr_service_pull_requests_merged_total",
+			"Total number of pull requests merged.", "tenant"),
+		reassignments: NewCounterVec("pr_service_reviewer_reassignments_total",
+			"Total number of reviewers replaced on open pull requests.", "tenant"),
+		noCandidate: NewCounterVec("pr_service_no_candidate_total",
+			"Total number of reassignments that failed with NO_CANDIDATE.", "tenant"),
+	}
+
+	reg.MustRegister(rec.httpRequests, rec.httpDuration, rec.prsCreated, rec.prsMerged, rec.reassignments, rec.noCandidate)
+
+	return rec
+}
+
+// ObserveRequest records a served request. route is the matched route
+// pattern rather than the raw path, to keep the number of series bounded.
+func (r *Recorder) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	r.httpRequests.Inc(method, route, strconv.Itoa(status))
+	r.httpDuration.Observe(elapsed.Seconds(), method, route)
+}
+
+func (r *Recorder) PRCreated(ctx context.Context) {
+	r.prsCreated.Inc(tenant.FromContext(ctx))
+}
+
+func (r *Recorder) PRMerged(ctx context.Context) {
+	r.prsMerged.Inc(tenant.FromContext(ctx))
+}
+
+func (r *Recorder) ReviewerReassigned(ctx context.Context) {
+	r.reassignments.Inc(tenant.FromContext(ctx))
+}
+
+func (r *Recorder) NoCandidate(ctx context.Context) {
+	r.noCandidate.Inc(tenant.FromContext(ctx))
+}
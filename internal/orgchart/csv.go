@@ -0,0 +1,119 @@
+package orgchart
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"pr-service/internal/domain"
+)
+
+var csvHeader = []string{"team_name", "parent_name", "user_id", "username", "role", "is_active"}
+
+func readCSV(r io.Reader) ([]row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, domain.ImportIssues{{Message: "the file is empty"}}
+	}
+	if err != nil {
+		return nil, csvIssue(err)
+	}
+
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !contains(csvHeader, name) {
+			return nil, domain.ImportIssues{{Line: 1, Message: fmt.Sprintf("unknown column %q, want %s", name, strings.Join(csvHeader, ","))}}
+		}
+		col[name] = i
+	}
+	if _, ok := col["team_name"]; !ok {
+		return nil, domain.ImportIssues{{Line: 1, Message: "team_name column is required"}}
+	}
+
+	_, hasParent := col["parent_name"]
+
+	var rows []row
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvIssue(err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := col[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		r := row{
+			line:      line,
+			team:      field("team_name"),
+			parent:    field("parent_name"),
+			parentSet: hasParent,
+			userID:    field("user_id"),
+			username:  field("username"),
+			role:      field("role"),
+			active:    field("is_active"),
+		}
+		r.hasUser = r.userID != "" || r.username != "" || r.role != "" || r.active != ""
+		rows = append(rows, r)
+	}
+
+	return rows, nil
+}
+
+func csvIssue(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return domain.ImportIssues{{Line: parseErr.Line, Message: parseErr.Err.Error()}}
+	}
+	return err
+}
+
+func writeCSV(w io.Writer, teams []domain.Team) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, t := range teams {
+		if len(t.Members) == 0 {
+			if err := cw.Write([]string{t.Name, t.ParentName, "", "", "", ""}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, m := range t.Members {
+			record := []string{t.Name, t.ParentName, m.ID, m.Username, string(m.Role), strconv.FormatBool(m.IsActive)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
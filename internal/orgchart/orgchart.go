@@ -0,0 +1,198 @@
+// Package orgchart reads and writes the team structure as CSV or YAML files
+// for bulk import and export. Export writes exactly what import accepts, so
+// a file can round-trip.
+//
+// CSV has one row per member under the header
+//
+//	team_name,parent_name,user_id,username,role,is_active
+//
+// A row with an empty user_id declares a team without adding a member, so
+// teams without members survive a round trip. YAML lists the teams:
+//
+//	teams:
+//	  - team_name: backend
+//	    parent_name: engineering
+//	    members:
+//	      - {user_id: u1, username: Alice, role: lead, is_active: true}
+package orgchart
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"pr-service/internal/domain"
+)
+
+// Formats.
+const (
+	CSV  = "csv"
+	YAML = "yaml"
+)
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	if format == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/yaml"
+}
+
+// FormatOf returns the format for a media type or a file extension, or ""
+// when it is neither CSV nor YAML.
+func FormatOf(s string) string {
+	if mediaType, _, err := mime.ParseMediaType(s); err == nil {
+		s = mediaType
+	}
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "csv", "text/csv":
+		return CSV
+	case "yaml", "yml", "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return YAML
+	default:
+		return ""
+	}
+}
+
+// Decode reads the teams in r. Problems with single rows are collected and
+// returned together as domain.ImportIssues.
+func Decode(format string, r io.Reader) ([]domain.ImportTeam, error) {
+	var (
+		rows []row
+		err  error
+	)
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case YAML:
+		rows, err = readYAML(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(rows)
+}
+
+// Encode writes teams in format.
+func Encode(format string, w io.Writer, teams []domain.Team) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, teams)
+	case YAML:
+		return writeYAML(w, teams)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// row is one team mention: a CSV line, or a YAML team or member.
+type row struct {
+	line     int
+	team     string
+	parent   string
+	hasUser  bool
+	userID   string
+	username string
+	role     string
+	// active is "" when not given, which means active.
+	active string
+	// parentSet is false when the parent was not given on this row; in CSV
+	// every row carries the column if the file has it, in YAML only the team
+	// entry does.
+	parentSet bool
+}
+
+// collect groups rows into teams and validates them.
+func collect(rows []row) ([]domain.ImportTeam, error) {
+	var (
+		issues  domain.ImportIssues
+		teams   []domain.ImportTeam
+		index   = make(map[string]int)
+		userRow = make(map[string]row)
+	)
+
+	for _, r := range rows {
+		issue := func(userID, format string, args ...any) {
+			issues = append(issues, domain.ImportIssue{Line: r.line, Team: r.team, UserID: userID, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if r.team == "" {
+			issue(r.userID, "team_name is required")
+			continue
+		}
+
+		i, ok := index[r.team]
+		if !ok {
+			i = len(teams)
+			index[r.team] = i
+			teams = append(teams, domain.ImportTeam{Team: domain.Team{Name: r.team, Members: []domain.User{}}, Line: r.line, KeepParent: true})
+		}
+		team := &teams[i]
+
+		if r.parentSet {
+			team.KeepParent = false
+			switch {
+			case r.parent == r.team:
+				issue("", "a team cannot be its own parent")
+			case team.ParentName == "":
+				team.ParentName = r.parent
+			case r.parent != "" && r.parent != team.ParentName:
+				issue("", "parent %s conflicts with parent %s given earlier", r.parent, team.ParentName)
+			}
+		}
+
+		if !r.hasUser {
+			continue
+		}
+		if r.userID == "" {
+			issue("", "user_id is required")
+			continue
+		}
+		if r.username == "" {
+			issue(r.userID, "username is required")
+			continue
+		}
+		if prev, ok := userRow[r.userID]; ok {
+			issue(r.userID, "user already listed on line %d", prev.line)
+			continue
+		}
+		userRow[r.userID] = r
+
+		// An empty role keeps the current one of an existing member.
+		role := domain.Role(strings.ToLower(r.role))
+		if role != "" && !role.IsValid() {
+			issue(r.userID, "%v", domain.ErrInvalidRole)
+			continue
+		}
+
+		active := true
+		switch strings.ToLower(r.active) {
+		case "", "true", "yes", "1":
+		case "false", "no", "0":
+			active = false
+		default:
+			issue(r.userID, "is_active must be true or false, got %q", r.active)
+			continue
+		}
+
+		team.Members = append(team.Members, domain.User{
+			ID:       r.userID,
+			Username: r.username,
+			IsActive: active,
+			TeamName: r.team,
+			Role:     role,
+		})
+	}
+
+	if len(issues) > 0 {
+		return nil, issues
+	}
+	if len(teams) == 0 {
+		return nil, domain.ImportIssues{{Message: "the file lists no teams"}}
+	}
+	return teams, nil
+}
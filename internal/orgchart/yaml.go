@@ -0,0 +1,158 @@
+package orgchart
+
+import (
+	"fmt"
+	"io"
+
+	"pr-service/internal/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlFile struct {
+	Teams []yamlTeam `yaml:"teams"`
+}
+
+type yamlTeam struct {
+	Name       string       `yaml:"team_name"`
+	ParentName string       `yaml:"parent_name,omitempty"`
+	Members    []yamlMember `yaml:"members"`
+}
+
+type yamlMember struct {
+	UserID   string `yaml:"user_id"`
+	Username string `yaml:"username"`
+	Role     string `yaml:"role"`
+	IsActive bool   `yaml:"is_active"`
+}
+
+var (
+	fileKeys   = []string{"teams"}
+	teamKeys   = []string{"team_name", "parent_name", "members"}
+	memberKeys = []string{"user_id", "username", "role", "is_active"}
+)
+
+// readYAML walks the document node by node instead of decoding it into
+// structs, so that every row keeps its line and unknown keys are reported.
+func readYAML(r io.Reader) ([]row, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil, domain.ImportIssues{{Message: "the file is empty"}}
+		}
+		return nil, domain.ImportIssues{{Message: err.Error()}}
+	}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	var issues domain.ImportIssues
+	issue := func(n *yaml.Node, team, userID, format string, args ...any) {
+		issues = append(issues, domain.ImportIssue{Line: n.Line, Team: team, UserID: userID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	fields, ok := mapping(root, fileKeys, issue)
+	if !ok {
+		return nil, issues
+	}
+	teamsNode := fields["teams"]
+	if teamsNode == nil || teamsNode.Kind != yaml.SequenceNode {
+		issue(root, "", "", "teams must be a list")
+		return nil, issues
+	}
+
+	var rows []row
+	for _, teamNode := range teamsNode.Content {
+		tf, ok := mapping(teamNode, teamKeys, issue)
+		if !ok {
+			continue
+		}
+
+		name := scalar(tf["team_name"])
+		rows = append(rows, row{
+			line:      teamNode.Line,
+			team:      name,
+			parent:    scalar(tf["parent_name"]),
+			parentSet: true,
+		})
+
+		members := tf["members"]
+		if members == nil {
+			continue
+		}
+		if members.Kind != yaml.SequenceNode {
+			issue(members, name, "", "members must be a list")
+			continue
+		}
+
+		for _, memberNode := range members.Content {
+			mf, ok := mapping(memberNode, memberKeys, issue)
+			if !ok {
+				continue
+			}
+			rows = append(rows, row{
+				line:     memberNode.Line,
+				team:     name,
+				hasUser:  true,
+				userID:   scalar(mf["user_id"]),
+				username: scalar(mf["username"]),
+				role:     scalar(mf["role"]),
+				active:   scalar(mf["is_active"]),
+			})
+		}
+	}
+
+	if len(issues) > 0 {
+		return nil, issues
+	}
+	return rows, nil
+}
+
+// mapping returns the values of a mapping node by key, reporting keys that
+// are not allowed.
+func mapping(n *yaml.Node, allowed []string, issue func(n *yaml.Node, team, userID, format string, args ...any)) (map[string]*yaml.Node, bool) {
+	if n.Kind != yaml.MappingNode {
+		issue(n, "", "", "expected a mapping of %v", allowed)
+		return nil, false
+	}
+
+	fields := make(map[string]*yaml.Node, len(n.Content)/2)
+	ok := true
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if !contains(allowed, key.Value) {
+			issue(key, "", "", "unknown key %q", key.Value)
+			ok = false
+			continue
+		}
+		fields[key.Value] = value
+	}
+	return fields, ok
+}
+
+func scalar(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+func writeYAML(w io.Writer, teams []domain.Team) error {
+	file := yamlFile{Teams: make([]yamlTeam, len(teams))}
+	for i, t := range teams {
+		members := make([]yamlMember, len(t.Members))
+		for j, m := range t.Members {
+			members[j] = yamlMember{UserID: m.ID, Username: m.Username, Role: string(m.Role), IsActive: m.IsActive}
+		}
+		file.Teams[i] = yamlTeam{Name: t.Name, ParentName: t.ParentName, Members: members}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,75 @@
+// Package ratelimit implements token-bucket rate limiting. Limiter hides
+// where the buckets live, so the in-memory store can later be replaced with
+// a shared one.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Per on average with bursts of up to Burst
+// requests. A zero Limit does not limit at all.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// rate is the refill speed in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// capacity is the bucket size; Requests when Burst is not set.
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// Decision is the outcome of a single Allow call.
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket capacity and Remaining the whole tokens left
+	// after this request.
+	Limit     int
+	Remaining int
+	// RetryAfter is how long to wait for the next token when the request
+	// was rejected.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket named key.
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// Policy maps request paths to limits. Each route listed in Routes has its
+// own bucket per client; all other routes share one Default bucket.
+type Policy struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// For returns the limit for path and the name of the bucket it is counted
+// in.
+func (p Policy) For(path string) (Limit, string) {
+	if l, ok := p.Routes[path]; ok {
+		return l, path
+	}
+	return p.Default, "*"
+}
+
+// Seconds rounds d up to whole seconds, as used by Retry-After.
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
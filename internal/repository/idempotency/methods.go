@@ -0,0 +1,143 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pr-service/internal/domain"
+	"pr-service/internal/tenant"
+	"time"
+)
+
+var errReservationLost = errors.New("idempotency key is no longer reserved")
+
+// Reserve claims rec.Key for rec.Fingerprint within the caller's tenant. When the key is already held by
+// an unexpired record it returns that record and false. An expired record of the same key, or a
+// reservation of it whose lease has run out, is dropped first; other expired records are left to
+// PurgeExpired.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	const (
+		queryExpire = `DELETE FROM idempotency_keys
+					   WHERE tenant_id = $1 AND idempotency_key = $2
+					   AND (expires_at < $3 OR (status_code IS NULL AND locked_until < $3))`
+		queryReserve = `INSERT INTO idempotency_keys (tenant_id, idempotency_key, fingerprint, expires_at, locked_until)
+						VALUES (:tenant_id, :idempotency_key, :fingerprint, :expires_at, :locked_until)
+						ON CONFLICT (tenant_id, idempotency_key) DO NOTHING`
+		queryGet = `SELECT idempotency_key, fingerprint, status_code, response_headers, response_body, expires_at, locked_until
+					FROM idempotency_keys WHERE idempotency_key = $1 AND tenant_id = $2`
+	)
+
+	tenantID := tenant.FromContext(ctx)
+
+	if _, err := r.conn(ctx).ExecContext(ctx, queryExpire, tenantID, rec.Key, time.Now()); err != nil {
+		return nil, false, fmt.Errorf("drop expired key: %w", err)
+	}
+
+	dbRec, err := fromDomain(tenantID, rec)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := r.conn(ctx).NamedExecContext(ctx, queryReserve, dbRec)
+	if err != nil {
+		return nil, false, fmt.Errorf("reserve key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 1 {
+		return nil, true, nil
+	}
+
+	var existing recordDB
+
+	err = r.conn(ctx).GetContext(ctx, &existing, queryGet, rec.Key, tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Released between our insert and select: report it as busy,
+			// the client retries anyway.
+			return &domain.IdempotencyRecord{Key: rec.Key, Fingerprint: rec.Fingerprint}, false, nil
+		}
+		return nil, false, fmt.Errorf("query key: %w", err)
+	}
+
+	found, err := existing.toDomain()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &found, false, nil
+}
+
+// Complete stores the response for a reservation made by Reserve. It fails
+// when the reservation is gone, e.g. because its lease ran out and a retry
+// took the key over.
+func (r *IdempotencyRepository) Complete(ctx context.Context, rec domain.IdempotencyRecord) error {
+	const query = `UPDATE idempotency_keys
+				   SET status_code = :status_code, response_headers = :response_headers, response_body = :response_body
+				   WHERE tenant_id = :tenant_id AND idempotency_key = :idempotency_key AND fingerprint = :fingerprint
+				   AND locked_until = :locked_until AND status_code IS NULL`
+
+	dbRec, err := fromDomain(tenant.FromContext(ctx), rec)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.conn(ctx).NamedExecContext(ctx, query, dbRec)
+	if err != nil {
+		return fmt.Errorf("complete key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return errReservationLost
+	}
+
+	return nil
+}
+
+// Release drops a reservation made by Reserve so the request can be retried.
+// A reservation that a retry has already taken over is left alone.
+func (r *IdempotencyRepository) Release(ctx context.Context, rec domain.IdempotencyRecord) error {
+	const query = `DELETE FROM idempotency_keys
+				   WHERE tenant_id = :tenant_id AND idempotency_key = :idempotency_key
+				   AND locked_until = :locked_until AND status_code IS NULL`
+
+	dbRec, err := fromDomain(tenant.FromContext(ctx), rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn(ctx).NamedExecContext(ctx, query, dbRec)
+	if err != nil {
+		return fmt.Errorf("release key: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the expired records of every tenant and returns how
+// many there were.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	const query = `DELETE FROM idempotency_keys WHERE expires_at < $1`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("purge expired keys: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,62 @@
+package idempotency
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"pr-service/internal/domain"
+	"time"
+)
+
+type recordDB struct {
+	TenantID    string        `db:"tenant_id"`
+	Key         string        `db:"idempotency_key"`
+	Fingerprint string        `db:"fingerprint"`
+	StatusCode  sql.NullInt64 `db:"status_code"`
+	Headers     []byte        `db:"response_headers"`
+	Body        []byte        `db:"response_body"`
+	ExpiresAt   time.Time     `db:"expires_at"`
+	LockedUntil time.Time     `db:"locked_until"`
+}
+
+func (r recordDB) toDomain() (domain.IdempotencyRecord, error) {
+	var headers map[string]string
+	if len(r.Headers) > 0 {
+		if err := json.Unmarshal(r.Headers, &headers); err != nil {
+			return domain.IdempotencyRecord{}, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+
+	return domain.IdempotencyRecord{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		StatusCode:  int(r.StatusCode.Int64),
+		Headers:     headers,
+		Body:        r.Body,
+		ExpiresAt:   r.ExpiresAt,
+		LockedUntil: r.LockedUntil,
+	}, nil
+}
+
+func fromDomain(tenantID string, rec domain.IdempotencyRecord) (recordDB, error) {
+	headers, err := json.Marshal(rec.Headers)
+	if err != nil {
+		return recordDB{}, fmt.Errorf("marshal headers: %w", err)
+	}
+
+	return recordDB{
+		TenantID:    tenantID,
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		StatusCode: sql.NullInt64{
+			Int64: int64(rec.StatusCode),
+			Valid: rec.StatusCode != 0,
+		},
+		Headers:   headers,
+		Body:      rec.Body,
+		ExpiresAt: rec.ExpiresAt,
+		// Postgres keeps microseconds; truncating here lets Complete and
+		// Release match the stored value exactly.
+		LockedUntil: rec.LockedUntil.Truncate(time.Microsecond),
+	}, nil
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"context"
+	"pr-service/internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// conn returns the transaction carried by ctx or the pool.
+func (r *IdempotencyRepository) conn(ctx context.Context) db.Querier {
+	return db.Conn(ctx, r.db)
+}
@@ -5,52 +5,75 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"pr-service/internal/db"
 	"pr-service/internal/domain"
+	"pr-service/internal/tenant"
+	"slices"
+
+	"github.com/lib/pq"
 )
 
-func (r *PRRepository) Create(ctx context.Context, pr domain.PullRequest) error {
+const uniqueViolation = "23505"
+
+func (r *PRRepository) Create(ctx context.Context, pr domain.PullRequest, trace domain.AssignmentTrace) error {
 	const (
-		queryCreatePR = `INSERT INTO pull_requests (pr_id, pr_name, author_id, status, created_at) 
-                     VALUES (:pr_id, :pr_name, :author_id, :status, :created_at)`
-		queryAssignReviewer = `INSERT INTO pull_request_reviewers (pr_id, reviewer_id) VALUES (:pr_id, :reviewer_id)`
+		queryCreatePR = `INSERT INTO pull_requests (tenant_id, pr_id, pr_name, author_id, status, created_at) 
+                     VALUES (:tenant_id, :pr_id, :pr_name, :author_id, :status, :created_at)`
+		queryAssignReviewer = `INSERT INTO pull_request_reviewers (tenant_id, pr_id, reviewer_id)
+							   VALUES (:tenant_id, :pr_id, :reviewer_id)`
 	)
 
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
-	}
-	defer tx.Rollback()
+	tenantID := tenant.FromContext(ctx)
 
-	dbPR := fromDomain(pr)
+	return db.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		dbPR := fromDomain(tenantID, pr)
 
-	_, err = tx.NamedExecContext(ctx, queryCreatePR, dbPR)
-	if err != nil {
-		return fmt.Errorf("insert pr: %w", err)
-	}
+		_, err := r.conn(ctx).NamedExecContext(ctx, queryCreatePR, dbPR)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return domain.ErrPRAlreadyExists
+			}
+			return fmt.Errorf("insert pr: %w", err)
+		}
 
-	if len(pr.AssignedReviewers) > 0 {
-		reviewers := toReviewerDB(pr.ID, pr.AssignedReviewers)
+		if len(pr.AssignedReviewers) > 0 {
+			reviewers := toReviewerDB(tenantID, pr.ID, pr.AssignedReviewers)
 
-		_, err = tx.NamedExecContext(ctx, queryAssignReviewer, reviewers)
-		if err != nil {
-			return fmt.Errorf("assign reviewers: %w", err)
+			_, err = r.conn(ctx).NamedExecContext(ctx, queryAssignReviewer, reviewers)
+			if err != nil {
+				return fmt.Errorf("assign reviewers: %w", err)
+			}
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
-	}
+		if err := r.saveAssignment(ctx, trace); err != nil {
+			return fmt.Errorf("save assignment: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r *PRRepository) GetByID(ctx context.Context, id string) (*domain.PullRequest, error) {
-	const query = `SELECT pr_id, pr_name, author_id, status, created_at, merged_at 
-                  FROM pull_requests WHERE pr_id = $1::text`
+	return r.getByID(ctx, id, false)
+}
+
+// GetByIDForUpdate is GetByID that also locks the PR row until the
+// surrounding transaction ends, so that concurrent updates of the PR are
+// serialized. Outside a transaction the lock is released right away.
+func (r *PRRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.PullRequest, error) {
+	return r.getByID(ctx, id, true)
+}
+
+func (r *PRRepository) getByID(ctx context.Context, id string, lock bool) (*domain.PullRequest, error) {
+	query := `SELECT pr_id, pr_name, author_id, status, created_at, merged_at, version 
+                  FROM pull_requests WHERE pr_id = $1::text AND tenant_id = $2`
+	if lock {
+		query += ` FOR UPDATE`
+	}
 
 	var dbPR prDB
 
-	err := r.db.GetContext(ctx, &dbPR, query, id)
+	err := r.conn(ctx).GetContext(ctx, &dbPR, query, id, tenant.FromContext(ctx))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -68,57 +91,126 @@ func (r *PRRepository) GetByID(ctx context.Context, id string) (*domain.PullRequ
 	return &result, nil
 }
 
-func (r *PRRepository) UpdatePR(ctx context.Context, request domain.PullRequest) error {
+// UpdatePR stores the status of the PR and bumps its version. A non-zero
+// expectedVersion makes the update conditional: ErrVersionMismatch is
+// returned when the stored version differs.
+func (r *PRRepository) UpdatePR(ctx context.Context, request domain.PullRequest, expectedVersion int) (int, error) {
 	const queryUpdatePRStatus = `
 		UPDATE pull_requests 
-		SET status = $2, merged_at = $3
-		WHERE pr_id = $1`
+		SET status = $2, merged_at = $3, version = version + 1
+		WHERE pr_id = $1 AND tenant_id = $5 AND ($4 = 0 OR version = $4)
+		RETURNING version`
+
+	var version int
 
-	_, err := r.db.ExecContext(ctx, queryUpdatePRStatus, request.ID, request.Status, request.MergedAt)
+	err := r.conn(ctx).GetContext(ctx, &version, queryUpdatePRStatus, request.ID, request.Status, request.MergedAt, expectedVersion, tenant.FromContext(ctx))
 	if err != nil {
-		return fmt.Errorf("update pr status: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, r.missingOrStale(ctx, request.ID)
+		}
+		return 0, fmt.Errorf("update pr status: %w", err)
 	}
-	return nil
+
+	return version, nil
 }
 
-func (r *PRRepository) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
-	const queryRemoveReviewer = `DELETE FROM pull_request_reviewers WHERE pr_id = $1 AND reviewer_id = $2`
-	const queryAssignReviewer = `INSERT INTO pull_request_reviewers (pr_id, reviewer_id) VALUES ($1::text, $2::text)`
+// ReassignReviewer swaps oldReviewerID for newReviewerID while holding a row
+// lock on the PR, so concurrent merges and reassignments are serialized.
+// It returns ErrVersionMismatch, ErrPRMerged, ErrNotAssigned or
+// ErrReviewerConflict when the state observed under the lock no longer allows
+// the swap. expectedVersion of 0 skips the version check. Every check runs
+// before the first write, so a failed swap changes nothing even when it runs
+// inside an outer transaction that is not rolled back.
+func (r *PRRepository) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string, expectedVersion int, trace domain.AssignmentTrace) error {
+	const queryLockPR = `SELECT status, version FROM pull_requests WHERE pr_id = $1 AND tenant_id = $2 FOR UPDATE`
+	const queryBumpVersion = `UPDATE pull_requests SET version = version + 1 WHERE pr_id = $1 AND tenant_id = $2`
+	const queryAssigned = `SELECT reviewer_id FROM pull_request_reviewers WHERE pr_id = $1 AND tenant_id = $2`
+	const queryRemoveReviewer = `DELETE FROM pull_request_reviewers WHERE pr_id = $1 AND reviewer_id = $2 AND tenant_id = $3`
+	const queryAssignReviewer = `INSERT INTO pull_request_reviewers (tenant_id, pr_id, reviewer_id) VALUES ($3, $1::text, $2::text)
+								 ON CONFLICT (tenant_id, pr_id, reviewer_id) DO NOTHING`
+
+	tenantID := tenant.FromContext(ctx)
+
+	return db.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		var locked lockedPRDB
+		err := r.conn(ctx).GetContext(ctx, &locked, queryLockPR, prID, tenantID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("lock pr: %w", err)
+		}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
-	}
-	defer tx.Rollback()
+		if expectedVersion != 0 && locked.Version != expectedVersion {
+			return domain.ErrVersionMismatch
+		}
 
-	_, err = tx.ExecContext(ctx, queryRemoveReviewer, prID, oldReviewerID)
-	if err != nil {
-		return fmt.Errorf("remove old reviewer: %w", err)
-	}
+		if locked.Status != domain.PRStatusOpen {
+			return domain.ErrPRMerged
+		}
 
-	_, err = tx.ExecContext(ctx, queryAssignReviewer, prID, newReviewerID)
-	if err != nil {
-		return fmt.Errorf("assign new reviewer: %w", err)
-	}
+		var assigned []string
+		if err := r.conn(ctx).SelectContext(ctx, &assigned, queryAssigned, prID, tenantID); err != nil {
+			return fmt.Errorf("get reviewers: %w", err)
+		}
+		if !slices.Contains(assigned, oldReviewerID) {
+			return domain.ErrNotAssigned
+		}
+		if slices.Contains(assigned, newReviewerID) {
+			return domain.ErrReviewerConflict
+		}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
-	}
+		result, err := r.conn(ctx).ExecContext(ctx, queryRemoveReviewer, prID, oldReviewerID, tenantID)
+		if err != nil {
+			return fmt.Errorf("remove old reviewer: %w", err)
+		}
 
-	return nil
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return domain.ErrNotAssigned
+		}
+
+		result, err = r.conn(ctx).ExecContext(ctx, queryAssignReviewer, prID, newReviewerID, tenantID)
+		if err != nil {
+			return fmt.Errorf("assign new reviewer: %w", err)
+		}
+
+		rows, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return domain.ErrReviewerConflict
+		}
+
+		if _, err := r.conn(ctx).ExecContext(ctx, queryBumpVersion, prID, tenantID); err != nil {
+			return fmt.Errorf("bump version: %w", err)
+		}
+
+		if err := r.saveAssignment(ctx, trace); err != nil {
+			return fmt.Errorf("save assignment: %w", err)
+		}
+
+		return nil
+	})
 }
 
 func (r *PRRepository) GetByReviewer(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
 	const query = `
-        SELECT pr.pr_id, pr.pr_name, pr.author_id, pr.status, pr.created_at, pr.merged_at
+        SELECT pr.pr_id, pr.pr_name, pr.author_id, pr.status, pr.created_at, pr.merged_at, pr.version
         FROM pull_requests pr
-        INNER JOIN pull_request_reviewers prr ON pr.pr_id = prr.pr_id
-        WHERE prr.reviewer_id = $1
+        INNER JOIN pull_request_reviewers prr ON pr.tenant_id = prr.tenant_id AND pr.pr_id = prr.pr_id
+        WHERE prr.reviewer_id = $1 AND prr.tenant_id = $2
         ORDER BY pr.created_at DESC`
 
 	var prsDB []prDB
 
-	err := r.db.SelectContext(ctx, &prsDB, query, reviewerID)
+	err := r.conn(ctx).SelectContext(ctx, &prsDB, query, reviewerID, tenant.FromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("query PRs by reviewer: %w", err)
 	}
@@ -137,13 +229,14 @@ func (r *PRRepository) GetByReviewer(ctx context.Context, reviewerID string) ([]
 }
 
 func (r *PRRepository) GetAllPRs(ctx context.Context) ([]domain.PullRequest, error) {
-	const query = `SELECT pr_id, pr_name, author_id, status, created_at, merged_at 
+	const query = `SELECT pr_id, pr_name, author_id, status, created_at, merged_at, version 
 				   FROM pull_requests 
+				   WHERE tenant_id = $1
 				   ORDER BY created_at DESC`
 
 	var prsDB []prDB
 
-	err := r.db.SelectContext(ctx, &prsDB, query)
+	err := r.conn(ctx).SelectContext(ctx, &prsDB, query, tenant.FromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("query all PRs: %w", err)
 	}
@@ -162,15 +255,155 @@ func (r *PRRepository) GetAllPRs(ctx context.Context) ([]domain.PullRequest, err
 	return prs, nil
 }
 
+func (r *PRRepository) GetRecentByAuthor(ctx context.Context, authorID string, limit int) ([]domain.PullRequest, error) {
+	const query = `SELECT pr_id, pr_name, author_id, status, created_at, merged_at, version
+				   FROM pull_requests
+				   WHERE author_id = $1 AND tenant_id = $3
+				   ORDER BY created_at DESC, pr_id DESC
+				   LIMIT $2`
+
+	var prsDB []prDB
+
+	err := r.conn(ctx).SelectContext(ctx, &prsDB, query, authorID, limit, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("query recent PRs by author: %w", err)
+	}
+
+	prs := make([]domain.PullRequest, len(prsDB))
+
+	for i, dbPR := range prsDB {
+		reviewers, err := r.getReviewers(ctx, dbPR.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get reviewers for PR %s: %w", dbPR.ID, err)
+		}
+
+		prs[i] = dbPR.toDomain(reviewers)
+	}
+
+	return prs, nil
+}
+
+// CountOpenByTeam counts open PRs authored or reviewed by members of the
+// team.
+func (r *PRRepository) CountOpenByTeam(ctx context.Context, teamName string) (int, error) {
+	const query = `SELECT COUNT(*) FROM pull_requests pr
+				   WHERE pr.tenant_id = $2 AND pr.status = 'OPEN'
+				   AND (
+					   EXISTS (SELECT 1 FROM users u
+							   WHERE u.tenant_id = pr.tenant_id AND u.user_id = pr.author_id AND u.team_name = $1)
+					   OR EXISTS (
+						   SELECT 1 FROM pull_request_reviewers prr
+						   JOIN users u ON u.tenant_id = prr.tenant_id AND u.user_id = prr.reviewer_id
+						   WHERE prr.tenant_id = pr.tenant_id AND prr.pr_id = pr.pr_id AND u.team_name = $1
+					   )
+				   )`
+
+	var count int
+
+	err := r.conn(ctx).GetContext(ctx, &count, query, teamName, tenant.FromContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("count open PRs by team: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountOpenReviewsByTeam counts reviews assigned on open PRs by the
+// reviewer's team. Unlike the other methods it covers all tenants: it feeds
+// the service-wide metrics.
+func (r *PRRepository) CountOpenReviewsByTeam(ctx context.Context) ([]domain.TeamReviewLoad, error) {
+	const query = `SELECT u.tenant_id, u.team_name, COUNT(*) AS open_reviews
+				   FROM pull_request_reviewers prr
+				   JOIN pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pr_id = prr.pr_id
+				   JOIN users u ON u.tenant_id = prr.tenant_id AND u.user_id = prr.reviewer_id
+				   WHERE pr.status = 'OPEN' AND u.team_name IS NOT NULL
+				   GROUP BY u.tenant_id, u.team_name
+				   ORDER BY u.tenant_id, u.team_name`
+
+	var loadsDB []reviewLoadDB
+
+	err := r.conn(ctx).SelectContext(ctx, &loadsDB, query)
+	if err != nil {
+		return nil, fmt.Errorf("count open reviews by team: %w", err)
+	}
+
+	loads := make([]domain.TeamReviewLoad, len(loadsDB))
+	for i, l := range loadsDB {
+		loads[i] = domain.TeamReviewLoad{TenantID: l.TenantID, TeamName: l.TeamName, OpenReviews: l.OpenReviews}
+	}
+
+	return loads, nil
+}
+
+func (r *PRRepository) GetAssignments(ctx context.Context, prID string) ([]domain.AssignmentTrace, error) {
+	const query = `SELECT pr_id, kind, strategy, seed, replaced_reviewer_id, trace, created_at
+				   FROM pull_request_assignments
+				   WHERE pr_id = $1 AND tenant_id = $2
+				   ORDER BY id`
+
+	var assignmentsDB []assignmentDB
+
+	err := r.conn(ctx).SelectContext(ctx, &assignmentsDB, query, prID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("query assignments: %w", err)
+	}
+
+	traces := make([]domain.AssignmentTrace, 0, len(assignmentsDB))
+	for _, a := range assignmentsDB {
+		trace, err := a.toDomain()
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, trace)
+	}
+
+	return traces, nil
+}
+
+func (r *PRRepository) saveAssignment(ctx context.Context, trace domain.AssignmentTrace) error {
+	const query = `INSERT INTO pull_request_assignments (tenant_id, pr_id, kind, strategy, seed, replaced_reviewer_id, trace)
+				   VALUES (:tenant_id, :pr_id, :kind, :strategy, :seed, :replaced_reviewer_id, :trace)`
+
+	dbAssignment, err := toAssignmentDB(tenant.FromContext(ctx), trace)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn(ctx).NamedExecContext(ctx, query, dbAssignment)
+	if err != nil {
+		return fmt.Errorf("insert assignment: %w", err)
+	}
+
+	return nil
+}
+
 func (r *PRRepository) getReviewers(ctx context.Context, prID string) ([]string, error) {
-	const query = `SELECT reviewer_id FROM pull_request_reviewers WHERE pr_id = $1`
+	const query = `SELECT reviewer_id FROM pull_request_reviewers WHERE pr_id = $1 AND tenant_id = $2`
 
 	var reviewers []string
 
-	err := r.db.SelectContext(ctx, &reviewers, query, prID)
+	err := r.conn(ctx).SelectContext(ctx, &reviewers, query, prID, tenant.FromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("query reviewers: %w", err)
 	}
 
 	return reviewers, nil
 }
+
+// missingOrStale tells apart the two reasons a conditional update can match
+// no rows.
+func (r *PRRepository) missingOrStale(ctx context.Context, prID string) error {
+	pr, err := r.GetByID(ctx, prID)
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		return domain.ErrNotFound
+	}
+	return domain.ErrVersionMismatch
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -1,24 +1,65 @@
 package pr
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"pr-service/internal/domain"
 	"time"
 )
 
 type prDB struct {
+	TenantID  string          `db:"tenant_id"`
 	ID        string          `db:"pr_id"`
 	Name      string          `db:"pr_name"`
 	AuthorID  string          `db:"author_id"`
 	Status    domain.PRStatus `db:"status"`
 	CreatedAt time.Time       `db:"created_at"`
 	MergedAt  *time.Time      `db:"merged_at"`
+	Version   int             `db:"version"`
+}
+
+type lockedPRDB struct {
+	Status  domain.PRStatus `db:"status"`
+	Version int             `db:"version"`
 }
 
 type reviewerDB struct {
+	TenantID   string `db:"tenant_id"`
 	PRID       string `db:"pr_id"`
 	ReviewerID string `db:"reviewer_id"`
 }
 
+type reviewLoadDB struct {
+	TenantID    string `db:"tenant_id"`
+	TeamName    string `db:"team_name"`
+	OpenReviews int    `db:"open_reviews"`
+}
+
+type assignmentDB struct {
+	TenantID           string         `db:"tenant_id"`
+	PRID               string         `db:"pr_id"`
+	Kind               string         `db:"kind"`
+	Strategy           string         `db:"strategy"`
+	Seed               int64          `db:"seed"`
+	ReplacedReviewerID sql.NullString `db:"replaced_reviewer_id"`
+	Trace              []byte         `db:"trace"`
+	CreatedAt          time.Time      `db:"created_at"`
+}
+
+type traceJSON struct {
+	Candidates    []candidateJSON `json:"candidates"`
+	Picked        []string        `json:"picked"`
+	FallbackTeams []string        `json:"fallback_teams,omitempty"`
+	LeadID        string          `json:"lead_id,omitempty"`
+}
+
+type candidateJSON struct {
+	UserID   string  `json:"user_id"`
+	Excluded string  `json:"excluded,omitempty"`
+	Weight   float64 `json:"weight,omitempty"`
+}
+
 func (p prDB) toDomain(reviewers []string) domain.PullRequest {
 	pr := domain.PullRequest{
 		ID:                p.ID,
@@ -28,13 +69,15 @@ func (p prDB) toDomain(reviewers []string) domain.PullRequest {
 		AssignedReviewers: reviewers,
 		CreatedAt:         p.CreatedAt,
 		MergedAt:          p.MergedAt,
+		Version:           p.Version,
 	}
 
 	return pr
 }
 
-func fromDomain(pr domain.PullRequest) prDB {
+func fromDomain(tenantID string, pr domain.PullRequest) prDB {
 	dbPR := prDB{
+		TenantID:  tenantID,
 		ID:        pr.ID,
 		Name:      pr.Name,
 		AuthorID:  pr.AuthorID,
@@ -46,11 +89,12 @@ func fromDomain(pr domain.PullRequest) prDB {
 	return dbPR
 }
 
-func toReviewerDB(prID string, reviewerIDs []string) []reviewerDB {
+func toReviewerDB(tenantID, prID string, reviewerIDs []string) []reviewerDB {
 	reviewers := make([]reviewerDB, 0, len(reviewerIDs))
 
 	for _, reviewerID := range reviewerIDs {
 		reviewers = append(reviewers, reviewerDB{
+			TenantID:   tenantID,
 			PRID:       prID,
 			ReviewerID: reviewerID,
 		})
@@ -58,3 +102,66 @@ func toReviewerDB(prID string, reviewerIDs []string) []reviewerDB {
 
 	return reviewers
 }
+
+func toAssignmentDB(tenantID string, trace domain.AssignmentTrace) (assignmentDB, error) {
+	payload := traceJSON{
+		Candidates:    make([]candidateJSON, 0, len(trace.Candidates)),
+		Picked:        trace.Picked,
+		FallbackTeams: trace.FallbackTeams,
+		LeadID:        trace.LeadID,
+	}
+	for _, c := range trace.Candidates {
+		payload.Candidates = append(payload.Candidates, candidateJSON{
+			UserID:   c.UserID,
+			Excluded: string(c.Excluded),
+			Weight:   c.Weight,
+		})
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return assignmentDB{}, fmt.Errorf("marshal trace: %w", err)
+	}
+
+	return assignmentDB{
+		TenantID: tenantID,
+		PRID:     trace.PRID,
+		Kind:     string(trace.Kind),
+		Strategy: trace.Strategy,
+		Seed:     trace.Seed,
+		ReplacedReviewerID: sql.NullString{
+			String: trace.ReplacedReviewerID,
+			Valid:  trace.ReplacedReviewerID != "",
+		},
+		Trace: raw,
+	}, nil
+}
+
+func (a assignmentDB) toDomain() (domain.AssignmentTrace, error) {
+	var payload traceJSON
+	if err := json.Unmarshal(a.Trace, &payload); err != nil {
+		return domain.AssignmentTrace{}, fmt.Errorf("unmarshal trace: %w", err)
+	}
+
+	candidates := make([]domain.AssignmentCandidate, 0, len(payload.Candidates))
+	for _, c := range payload.Candidates {
+		candidates = append(candidates, domain.AssignmentCandidate{
+			UserID:   c.UserID,
+			Excluded: domain.ExclusionReason(c.Excluded),
+			Weight:   c.Weight,
+		})
+	}
+
+	return domain.AssignmentTrace{
+		PRID:               a.PRID,
+		Kind:               domain.AssignmentKind(a.Kind),
+		Strategy:           a.Strategy,
+		Seed:               a.Seed,
+		ReplacedReviewerID: a.ReplacedReviewerID.String,
+		Candidates:         candidates,
+		Picked:             payload.Picked,
+		FallbackTeams:      payload.FallbackTeams,
+		LeadID:             payload.LeadID,
+		CreatedAt:          a.CreatedAt,
+	}, nil
+}
@@ -1,6 +1,11 @@
 package pr
 
-import "github.com/jmoiron/sqlx"
+import (
+	"context"
+	"pr-service/internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
 
 type PRRepository struct {
 	db *sqlx.DB
@@ -9,3 +14,8 @@ type PRRepository struct {
 func NewPRRepository(db *sqlx.DB) *PRRepository {
 	return &PRRepository{db: db}
 }
+
+// conn returns the transaction carried by ctx or the pool.
+func (r *PRRepository) conn(ctx context.Context) db.Querier {
+	return db.Conn(ctx, r.db)
+}
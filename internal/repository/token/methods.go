@@ -0,0 +1,74 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pr-service/internal/domain"
+	"pr-service/internal/tenant"
+	"time"
+)
+
+const tokenColumns = `token_id, tenant_id, name, token_hash, scope, user_id, created_at, revoked_at`
+
+func (r *TokenRepository) CreateToken(ctx context.Context, token domain.APIToken) error {
+	const query = `INSERT INTO api_tokens (token_id, tenant_id, name, token_hash, scope, user_id, created_at)
+				   VALUES (:token_id, :tenant_id, :name, :token_hash, :scope, :user_id, :created_at)`
+
+	if _, err := r.conn(ctx).NamedExecContext(ctx, query, fromDomain(token)); err != nil {
+		return fmt.Errorf("insert token: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenByHash returns the token with the given secret hash, or nil. It
+// looks across all tenants: the token decides which tenant the request runs
+// in.
+func (r *TokenRepository) GetTokenByHash(ctx context.Context, hash string) (*domain.APIToken, error) {
+	return r.get(ctx, `SELECT `+tokenColumns+` FROM api_tokens WHERE token_hash = $1`, hash)
+}
+
+// GetTokenByID returns the token with the given id, or nil.
+func (r *TokenRepository) GetTokenByID(ctx context.Context, id string) (*domain.APIToken, error) {
+	return r.get(ctx, `SELECT `+tokenColumns+` FROM api_tokens WHERE token_id = $1 AND tenant_id = $2`,
+		id, tenant.FromContext(ctx))
+}
+
+// RevokeToken marks the token revoked. Revoking it again keeps the original
+// revocation time.
+func (r *TokenRepository) RevokeToken(ctx context.Context, id string, at time.Time) error {
+	const query = `UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, $2) WHERE token_id = $1 AND tenant_id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, id, at, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) get(ctx context.Context, query string, args ...any) (*domain.APIToken, error) {
+	var t tokenDB
+
+	err := r.conn(ctx).GetContext(ctx, &t, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query token: %w", err)
+	}
+
+	token := t.toDomain()
+	return &token, nil
+}
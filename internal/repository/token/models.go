@@ -0,0 +1,46 @@
+package token
+
+import (
+	"database/sql"
+	"pr-service/internal/domain"
+	"time"
+)
+
+type tokenDB struct {
+	ID        string         `db:"token_id"`
+	TenantID  string         `db:"tenant_id"`
+	Name      string         `db:"name"`
+	Hash      string         `db:"token_hash"`
+	Scope     string         `db:"scope"`
+	UserID    sql.NullString `db:"user_id"`
+	CreatedAt time.Time      `db:"created_at"`
+	RevokedAt sql.NullTime   `db:"revoked_at"`
+}
+
+func (t tokenDB) toDomain() domain.APIToken {
+	token := domain.APIToken{
+		ID:        t.ID,
+		TenantID:  t.TenantID,
+		Name:      t.Name,
+		Hash:      t.Hash,
+		Scope:     domain.Scope(t.Scope),
+		UserID:    t.UserID.String,
+		CreatedAt: t.CreatedAt,
+	}
+	if t.RevokedAt.Valid {
+		token.RevokedAt = &t.RevokedAt.Time
+	}
+	return token
+}
+
+func fromDomain(t domain.APIToken) tokenDB {
+	return tokenDB{
+		ID:        t.ID,
+		TenantID:  t.TenantID,
+		Name:      t.Name,
+		Hash:      t.Hash,
+		Scope:     string(t.Scope),
+		UserID:    sql.NullString{String: t.UserID, Valid: t.UserID != ""},
+		CreatedAt: t.CreatedAt,
+	}
+}
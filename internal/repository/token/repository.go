@@ -0,0 +1,21 @@
+package token
+
+import (
+	"context"
+	"pr-service/internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// conn returns the transaction carried by ctx or the pool.
+func (r *TokenRepository) conn(ctx context.Context) db.Querier {
+	return db.Conn(ctx, r.db)
+}
@@ -5,43 +5,47 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"pr-service/internal/db"
 	"pr-service/internal/domain"
-
-	"github.com/jmoiron/sqlx"
+	"pr-service/internal/tenant"
+	"strings"
+	"time"
 )
 
 func (r *UserTeamRepository) CreateTeam(ctx context.Context, team domain.Team) error {
-	const queryCreateTeam = `
-		INSERT INTO teams (name) 
-		SELECT $1::text 
-		WHERE NOT EXISTS (SELECT 1 FROM teams WHERE name = $1::text)`
+	const queryCreateTeam = `INSERT INTO teams (tenant_id, name, parent_name) VALUES ($3, $1, NULLIF($2, ''))
+							   ON CONFLICT (tenant_id, name) DO NOTHING`
 
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
-	}
-	defer tx.Rollback()
+	return db.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		result, err := r.conn(ctx).ExecContext(ctx, queryCreateTeam, team.Name, team.ParentName, tenant.FromContext(ctx))
+		if err != nil {
+			return fmt.Errorf("insert team: %w", err)
+		}
 
-	_, err = tx.ExecContext(ctx, queryCreateTeam, team.Name)
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
 
-	err = r.createUsers(ctx, tx, team)
-	if err != nil {
-		return fmt.Errorf("createUser: %w", err)
+		if rows == 0 {
+			return domain.ErrTeamAlreadyExists
+		}
 
-	}
+		err = r.upsertUsers(ctx, team.Name, team.Members)
+		if err != nil {
+			return fmt.Errorf("upsertUsers: %w", err)
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
-	}
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r *UserTeamRepository) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
-	const query = "SELECT user_id, username, team_name, is_active FROM users WHERE user_id = $1::text"
+	const query = "SELECT user_id, username, team_name, is_active, role FROM users WHERE user_id = $1::text AND tenant_id = $2"
 	var dbUser userDB
 
-	err := r.db.GetContext(ctx, &dbUser, query, userID)
+	err := r.conn(ctx).GetContext(ctx, &dbUser, query, userID, tenant.FromContext(ctx))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -56,13 +60,13 @@ func (r *UserTeamRepository) GetUserByID(ctx context.Context, userID string) (*d
 }
 
 func (r *UserTeamRepository) GetUsersByTeam(ctx context.Context, teamName string) ([]domain.User, error) {
-	const query = `SELECT user_id, username, team_name, is_active 
+	const query = `SELECT user_id, username, team_name, is_active, role 
 				   FROM users 
-				   WHERE team_name = $1`
+				   WHERE team_name = $1 AND tenant_id = $2`
 
 	var dbUser []userDB
 
-	err := r.db.SelectContext(ctx, &dbUser, query, teamName)
+	err := r.conn(ctx).SelectContext(ctx, &dbUser, query, teamName, tenant.FromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("query users by team: %w", err)
 	}
@@ -76,33 +80,167 @@ func (r *UserTeamRepository) GetUsersByTeam(ctx context.Context, teamName string
 }
 
 func (r *UserTeamRepository) GetByName(ctx context.Context, name string) (*domain.Team, error) {
-	const query = "SELECT user_id, username, team_name, is_active FROM users WHERE team_name = $1"
+	const (
+		queryTeam  = "SELECT name, parent_name, archived_at FROM teams WHERE name = $1 AND tenant_id = $2"
+		queryUsers = "SELECT user_id, username, team_name, is_active, role FROM users WHERE team_name = $1 AND tenant_id = $2"
+	)
+
+	tenantID := tenant.FromContext(ctx)
+
+	var team teamDB
+
+	err := r.conn(ctx).GetContext(ctx, &team, queryTeam, name, tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("query team: %w", err)
+	}
 
 	var userDb []userDB
 
-	err := r.db.SelectContext(ctx, &userDb, query, name)
+	err = r.conn(ctx).SelectContext(ctx, &userDb, queryUsers, name, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("query users by name: %w", err)
 	}
 
-	if len(userDb) == 0 {
-		return nil, nil
-	}
 	users := make([]domain.User, 0, len(userDb))
 	for _, db := range userDb {
 		users = append(users, db.toDomain())
 	}
 
-	return &domain.Team{
-		Name:    name,
-		Members: users,
-	}, nil
+	return team.ToDomain(users), nil
+}
+
+// ListTeams returns teams ordered by name with member counts, and the total
+// number of teams matching. Archived teams are skipped unless
+// includeArchived is set.
+func (r *UserTeamRepository) ListTeams(ctx context.Context, includeArchived bool, page domain.Page) ([]domain.TeamSummary, int, error) {
+	const (
+		queryCount = `SELECT COUNT(*) FROM teams WHERE tenant_id = $2 AND ($1 OR archived_at IS NULL)`
+		queryList  = `SELECT t.name, t.archived_at,
+						  COUNT(u.user_id) AS member_count,
+						  COUNT(u.user_id) FILTER (WHERE u.is_active) AS active_count
+					  FROM teams t
+					  LEFT JOIN users u ON u.tenant_id = t.tenant_id AND u.team_name = t.name
+					  WHERE t.tenant_id = $4 AND ($1 OR t.archived_at IS NULL)
+					  GROUP BY t.name, t.archived_at
+					  ORDER BY t.name
+					  LIMIT $2 OFFSET $3`
+	)
+
+	tenantID := tenant.FromContext(ctx)
+
+	var total int
+
+	err := r.conn(ctx).GetContext(ctx, &total, queryCount, includeArchived, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count teams: %w", err)
+	}
+
+	var teamsDB []teamSummaryDB
+
+	err = r.conn(ctx).SelectContext(ctx, &teamsDB, queryList, includeArchived, page.Limit, page.Offset, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query teams: %w", err)
+	}
+
+	teams := make([]domain.TeamSummary, 0, len(teamsDB))
+	for _, t := range teamsDB {
+		teams = append(teams, t.toDomain())
+	}
+
+	return teams, total, nil
+}
+
+// ListTeamsWithMembers returns every team that is not archived, ordered by
+// name, with its members ordered by id.
+func (r *UserTeamRepository) ListTeamsWithMembers(ctx context.Context) ([]domain.Team, error) {
+	const (
+		queryTeams = `SELECT name, parent_name, archived_at FROM teams
+					  WHERE tenant_id = $1 AND archived_at IS NULL
+					  ORDER BY name`
+		queryUsers = `SELECT u.user_id, u.username, u.team_name, u.is_active, u.role
+					  FROM users u
+					  JOIN teams t ON t.tenant_id = u.tenant_id AND t.name = u.team_name
+					  WHERE u.tenant_id = $1 AND t.archived_at IS NULL
+					  ORDER BY u.user_id`
+	)
+
+	tenantID := tenant.FromContext(ctx)
+
+	var teamsDB []teamDB
+
+	err := r.conn(ctx).SelectContext(ctx, &teamsDB, queryTeams, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query teams: %w", err)
+	}
+
+	var usersDB []userDB
+
+	err = r.conn(ctx).SelectContext(ctx, &usersDB, queryUsers, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query team members: %w", err)
+	}
+
+	members := make(map[string][]domain.User, len(teamsDB))
+	for _, u := range usersDB {
+		members[u.TeamName.String] = append(members[u.TeamName.String], u.toDomain())
+	}
+
+	teams := make([]domain.Team, 0, len(teamsDB))
+	for _, t := range teamsDB {
+		teams = append(teams, *t.ToDomain(members[t.Name]))
+	}
+
+	return teams, nil
+}
+
+// SearchUsers returns users matching filter ordered by id, and the total
+// number of matches. The username prefix is matched case-insensitively.
+func (r *UserTeamRepository) SearchUsers(ctx context.Context, filter domain.UserFilter, page domain.Page) ([]domain.User, int, error) {
+	const (
+		where = `WHERE tenant_id = $4
+				 AND ($1 = '' OR username ILIKE $1 || '%')
+				 AND ($2 = '' OR team_name = $2)
+				 AND ($3::boolean IS NULL OR is_active = $3)`
+		queryCount  = `SELECT COUNT(*) FROM users ` + where
+		querySearch = `SELECT user_id, username, team_name, is_active, role FROM users ` + where + `
+					   ORDER BY user_id
+					   LIMIT $5 OFFSET $6`
+	)
+
+	prefix := escapeLike(filter.UsernamePrefix)
+	tenantID := tenant.FromContext(ctx)
+
+	var total int
+
+	err := r.conn(ctx).GetContext(ctx, &total, queryCount, prefix, filter.TeamName, filter.IsActive, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	var usersDB []userDB
+
+	err = r.conn(ctx).SelectContext(ctx, &usersDB, querySearch, prefix, filter.TeamName, filter.IsActive, tenantID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search users: %w", err)
+	}
+
+	users := make([]domain.User, 0, len(usersDB))
+	for _, u := range usersDB {
+		users = append(users, u.toDomain())
+	}
+
+	return users, total, nil
 }
 
 func (r *UserTeamRepository) SetUserActive(ctx context.Context, req domain.ActivateUserRequest) error {
-	result, err := r.db.ExecContext(ctx, "UPDATE users SET is_active = $1 WHERE user_id = $2",
+	result, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET is_active = $1 WHERE user_id = $2 AND tenant_id = $3",
 		req.IsActive,
-		req.UserID)
+		req.UserID,
+		tenant.FromContext(ctx))
 
 	if err != nil {
 		return fmt.Errorf("update user active status: %w", err)
@@ -120,35 +258,346 @@ func (r *UserTeamRepository) SetUserActive(ctx context.Context, req domain.Activ
 	return nil
 }
 
-func (r *UserTeamRepository) createUsers(ctx context.Context, tx *sqlx.Tx, team domain.Team) error {
-	const query = `
-INSERT INTO users (user_id, username, team_name, is_active)
-VALUES (:user_id, :username, :team_name, :is_active)
-ON CONFLICT (user_id) DO NOTHING`
+// AddMembers puts members into the team. Users that already exist get the
+// username and activity from the request and are moved to the team.
+func (r *UserTeamRepository) AddMembers(ctx context.Context, teamName string, members []domain.User) error {
+	return r.upsertUsers(ctx, teamName, members)
+}
 
-	dbUsers := make([]userDB, 0, len(team.Members))
+// SetUserTeam moves the user to teamName as a regular member.
+func (r *UserTeamRepository) SetUserTeam(ctx context.Context, userID, teamName string) error {
+	const query = `UPDATE users SET team_name = $2, role = 'member' WHERE user_id = $1 AND tenant_id = $3`
 
-	for _, u := range team.Members {
-		dbUsers = append(dbUsers, fromDomain(u, team.Name))
+	result, err := r.conn(ctx).ExecContext(ctx, query, userID, teamName, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("update user team: %w", err)
 	}
 
-	_, err := tx.NamedExecContext(ctx, query, dbUsers)
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("insert users: %w", err)
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotFound
 	}
 
 	return nil
 }
 
+// RemoveFromTeam detaches the user from teamName and deactivates them, so
+// they no longer show up as a reviewer candidate anywhere.
+func (r *UserTeamRepository) RemoveFromTeam(ctx context.Context, teamName, userID string) error {
+	const query = `UPDATE users 
+				   SET team_name = NULL, is_active = FALSE, role = 'member' 
+				   WHERE user_id = $1 AND team_name = $2 AND tenant_id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, userID, teamName, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("remove user from team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotTeamMember
+	}
+
+	return nil
+}
+
+// SetRole changes the role of a member of teamName.
+func (r *UserTeamRepository) SetRole(ctx context.Context, teamName, userID string, role domain.Role) error {
+	const query = `UPDATE users SET role = $3 WHERE user_id = $1 AND team_name = $2 AND tenant_id = $4`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, userID, teamName, string(role), tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("set user role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotTeamMember
+	}
+
+	return nil
+}
+
+func (r *UserTeamRepository) UpdateUsername(ctx context.Context, userID, username string) error {
+	const query = `UPDATE users SET username = $2 WHERE user_id = $1 AND tenant_id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, userID, username, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("update username: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// upsertUsers creates the members in teamName and updates the ones that
+// exist. A member without a role keeps the stored one, new users get the
+// column default.
+func (r *UserTeamRepository) upsertUsers(ctx context.Context, teamName string, members []domain.User) error {
+	const (
+		queryWithRole = `
+INSERT INTO users (tenant_id, user_id, username, team_name, is_active, role)
+VALUES (:tenant_id, :user_id, :username, :team_name, :is_active, :role)
+ON CONFLICT (tenant_id, user_id) DO UPDATE
+SET username = EXCLUDED.username, team_name = EXCLUDED.team_name, is_active = EXCLUDED.is_active,
+    role = EXCLUDED.role`
+		queryKeepRole = `
+INSERT INTO users (tenant_id, user_id, username, team_name, is_active)
+VALUES (:tenant_id, :user_id, :username, :team_name, :is_active)
+ON CONFLICT (tenant_id, user_id) DO UPDATE
+SET username = EXCLUDED.username, team_name = EXCLUDED.team_name, is_active = EXCLUDED.is_active`
+	)
+
+	if len(members) == 0 {
+		return nil
+	}
+
+	// A single INSERT cannot touch the same row twice, so repeated ids in
+	// the request collapse to the last occurrence.
+	last := make(map[string]domain.User, len(members))
+	order := make([]string, 0, len(members))
+	for _, u := range members {
+		if _, ok := last[u.ID]; !ok {
+			order = append(order, u.ID)
+		}
+		last[u.ID] = u
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	var withRole, keepRole []userDB
+	for _, id := range order {
+		u := last[id]
+		if u.Role == "" {
+			keepRole = append(keepRole, fromDomain(tenantID, u, teamName))
+		} else {
+			withRole = append(withRole, fromDomain(tenantID, u, teamName))
+		}
+	}
+
+	if len(withRole) > 0 {
+		if _, err := r.conn(ctx).NamedExecContext(ctx, queryWithRole, withRole); err != nil {
+			return fmt.Errorf("upsert users: %w", err)
+		}
+	}
+	if len(keepRole) > 0 {
+		if _, err := r.conn(ctx).NamedExecContext(ctx, queryKeepRole, keepRole); err != nil {
+			return fmt.Errorf("upsert users: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ArchiveTeam marks the team archived at the given time. Archiving an
+// already archived team keeps the original time.
+func (r *UserTeamRepository) ArchiveTeam(ctx context.Context, name string, at time.Time) error {
+	const query = `UPDATE teams SET archived_at = COALESCE(archived_at, $2) WHERE name = $1 AND tenant_id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, name, at, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("archive team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteTeam removes the team. The schema cascades the delete to its members
+// and the pull requests they authored.
+func (r *UserTeamRepository) DeleteTeam(ctx context.Context, name string) error {
+	const query = `DELETE FROM teams WHERE name = $1 AND tenant_id = $2`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, name, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("delete team: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetParent attaches the team to parent, or detaches it when parent is
+// empty. It returns ErrTeamCycle when parent is the team itself or one of its
+// descendants. Hierarchy changes are serialized so that two concurrent moves
+// cannot close a cycle together.
+func (r *UserTeamRepository) SetParent(ctx context.Context, name, parent string) error {
+	const (
+		queryLock       = `SELECT pg_advisory_xact_lock(hashtext('teams_hierarchy:' || $1))`
+		queryIsAncestor = `
+			WITH RECURSIVE ancestors AS (
+				SELECT name, parent_name FROM teams WHERE name = $2 AND tenant_id = $3
+				UNION
+				SELECT t.name, t.parent_name FROM teams t
+				JOIN ancestors a ON t.name = a.parent_name
+				WHERE t.tenant_id = $3
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE name = $1)`
+		querySetParent = `UPDATE teams SET parent_name = NULLIF($2, '') WHERE name = $1 AND tenant_id = $3`
+	)
+
+	tenantID := tenant.FromContext(ctx)
+
+	return db.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		if _, err := r.conn(ctx).ExecContext(ctx, queryLock, tenantID); err != nil {
+			return fmt.Errorf("lock hierarchy: %w", err)
+		}
+
+		if parent != "" {
+			var cycle bool
+			if err := r.conn(ctx).GetContext(ctx, &cycle, queryIsAncestor, name, parent, tenantID); err != nil {
+				return fmt.Errorf("check ancestors: %w", err)
+			}
+			if cycle {
+				return domain.ErrTeamCycle
+			}
+		}
+
+		result, err := r.conn(ctx).ExecContext(ctx, querySetParent, name, parent, tenantID)
+		if err != nil {
+			return fmt.Errorf("set parent: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return domain.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetTree returns the subtree rooted at root, or every tree when root is
+// empty, parents before children. Each node carries the counters of its own
+// members only.
+func (r *UserTeamRepository) GetTree(ctx context.Context, root string, includeArchived bool) ([]domain.TeamNode, error) {
+	const query = `
+		WITH RECURSIVE tree AS (
+			SELECT name, parent_name, archived_at, 0 AS depth
+			FROM teams
+			WHERE tenant_id = $3
+			  AND (CASE WHEN $1 = '' THEN parent_name IS NULL ELSE name = $1 END)
+			  AND ($2 OR archived_at IS NULL)
+			UNION ALL
+			SELECT t.name, t.parent_name, t.archived_at, tree.depth + 1
+			FROM teams t
+			JOIN tree ON t.parent_name = tree.name
+			WHERE t.tenant_id = $3 AND ($2 OR t.archived_at IS NULL)
+		)
+		SELECT tree.name, tree.parent_name, tree.archived_at,
+			COUNT(u.user_id) AS members,
+			COUNT(u.user_id) FILTER (WHERE u.is_active) AS active_members,
+			COALESCE(SUM(pr.open_prs), 0) AS open_prs,
+			COALESCE(SUM(pr.merged_prs), 0) AS merged_prs
+		FROM tree
+		LEFT JOIN users u ON u.tenant_id = $3 AND u.team_name = tree.name
+		LEFT JOIN (
+			SELECT author_id,
+				COUNT(*) FILTER (WHERE status = 'OPEN') AS open_prs,
+				COUNT(*) FILTER (WHERE status = 'MERGED') AS merged_prs
+			FROM pull_requests
+			WHERE tenant_id = $3
+			GROUP BY author_id
+		) pr ON pr.author_id = u.user_id
+		GROUP BY tree.name, tree.parent_name, tree.archived_at, tree.depth
+		ORDER BY tree.depth, tree.name`
+
+	var nodesDB []teamNodeDB
+
+	err := r.conn(ctx).SelectContext(ctx, &nodesDB, query, root, includeArchived, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("query team tree: %w", err)
+	}
+
+	nodes := make([]domain.TeamNode, 0, len(nodesDB))
+	for _, n := range nodesDB {
+		nodes = append(nodes, n.toDomain())
+	}
+
+	return nodes, nil
+}
+
+// GetSiblingMembers returns the members of the other active teams sharing
+// the team's parent. A team without a parent has no siblings.
+func (r *UserTeamRepository) GetSiblingMembers(ctx context.Context, teamName string) ([]domain.User, error) {
+	const query = `SELECT u.user_id, u.username, u.team_name, u.is_active, u.role
+				   FROM users u
+				   JOIN teams t ON t.tenant_id = u.tenant_id AND t.name = u.team_name
+				   JOIN teams me ON me.tenant_id = u.tenant_id AND me.name = $1
+				   WHERE u.tenant_id = $2
+				     AND t.parent_name = me.parent_name
+				     AND t.name <> me.name
+				     AND t.archived_at IS NULL
+				   ORDER BY u.user_id`
+
+	var usersDB []userDB
+
+	err := r.conn(ctx).SelectContext(ctx, &usersDB, query, teamName, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("query sibling members: %w", err)
+	}
+
+	users := make([]domain.User, 0, len(usersDB))
+	for _, u := range usersDB {
+		users = append(users, u.toDomain())
+	}
+
+	return users, nil
+}
+
 func (r *UserTeamRepository) DeactivateByTeam(ctx context.Context, teamName string) error {
 	const query = `UPDATE users 
 				   SET is_active = FALSE 
-				   WHERE team_name = $1;`
+				   WHERE team_name = $1 AND tenant_id = $2;`
 
-	_, err := r.db.ExecContext(ctx, query, teamName)
+	_, err := r.conn(ctx).ExecContext(ctx, query, teamName, tenant.FromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("deactivated: %w", err)
 	}
 
 	return nil
 }
+
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
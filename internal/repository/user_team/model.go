@@ -1,38 +1,103 @@
 package user_team
 
-import "pr-service/internal/domain"
+import (
+	"database/sql"
+	"pr-service/internal/domain"
+)
 
 type teamDB struct {
-	Name string `db:"name"`
+	Name       string         `db:"name"`
+	ParentName sql.NullString `db:"parent_name"`
+	ArchivedAt sql.NullTime   `db:"archived_at"`
 }
+
+type teamNodeDB struct {
+	Name          string         `db:"name"`
+	ParentName    sql.NullString `db:"parent_name"`
+	ArchivedAt    sql.NullTime   `db:"archived_at"`
+	Members       int            `db:"members"`
+	ActiveMembers int            `db:"active_members"`
+	OpenPRs       int            `db:"open_prs"`
+	MergedPRs     int            `db:"merged_prs"`
+}
+type teamSummaryDB struct {
+	Name        string       `db:"name"`
+	ArchivedAt  sql.NullTime `db:"archived_at"`
+	MemberCount int          `db:"member_count"`
+	ActiveCount int          `db:"active_count"`
+}
+
 type userDB struct {
-	ID       string `db:"user_id"`
-	Username string `db:"username"`
-	TeamName string `db:"team_name"`
-	IsActive bool   `db:"is_active"`
+	TenantID string         `db:"tenant_id"`
+	ID       string         `db:"user_id"`
+	Username string         `db:"username"`
+	TeamName sql.NullString `db:"team_name"`
+	IsActive bool           `db:"is_active"`
+	Role     string         `db:"role"`
 }
 
 func (u *userDB) toDomain() domain.User {
 	return domain.User{
 		ID:       u.ID,
 		Username: u.Username,
-		TeamName: u.TeamName,
+		TeamName: u.TeamName.String,
 		IsActive: u.IsActive,
+		Role:     domain.Role(u.Role),
 	}
 }
 
-func fromDomain(user domain.User, teamName string) userDB {
+func fromDomain(tenantID string, user domain.User, teamName string) userDB {
+	if user.Role == "" {
+		user.Role = domain.RoleMember
+	}
+
 	return userDB{
+		TenantID: tenantID,
 		ID:       user.ID,
 		Username: user.Username,
-		TeamName: teamName,
+		TeamName: sql.NullString{String: teamName, Valid: teamName != ""},
 		IsActive: user.IsActive,
+		Role:     string(user.Role),
 	}
 }
 
 func (t *teamDB) ToDomain(members []domain.User) *domain.Team {
-	return &domain.Team{
-		Name:    t.Name,
-		Members: members,
+	team := &domain.Team{
+		Name:       t.Name,
+		ParentName: t.ParentName.String,
+		Members:    members,
+	}
+	if t.ArchivedAt.Valid {
+		team.ArchivedAt = &t.ArchivedAt.Time
+	}
+	return team
+}
+
+func (t *teamSummaryDB) toDomain() domain.TeamSummary {
+	summary := domain.TeamSummary{
+		Name:        t.Name,
+		MemberCount: t.MemberCount,
+		ActiveCount: t.ActiveCount,
+	}
+	if t.ArchivedAt.Valid {
+		summary.ArchivedAt = &t.ArchivedAt.Time
+	}
+	return summary
+}
+
+func (t *teamNodeDB) toDomain() domain.TeamNode {
+	node := domain.TeamNode{
+		Name:       t.Name,
+		ParentName: t.ParentName.String,
+		Own: domain.TeamStats{
+			Members:       t.Members,
+			ActiveMembers: t.ActiveMembers,
+			OpenPRs:       t.OpenPRs,
+			MergedPRs:     t.MergedPRs,
+		},
+	}
+	if t.ArchivedAt.Valid {
+		node.ArchivedAt = &t.ArchivedAt.Time
 	}
+	return node
 }
@@ -1,6 +1,11 @@
 package user_team
 
-import "github.com/jmoiron/sqlx"
+import (
+	"context"
+	"pr-service/internal/db"
+
+	"github.com/jmoiron/sqlx"
+)
 
 type UserTeamRepository struct {
 	db *sqlx.DB
@@ -9,3 +14,8 @@ type UserTeamRepository struct {
 func NewUserTeamRepository(db *sqlx.DB) *UserTeamRepository {
 	return &UserTeamRepository{db: db}
 }
+
+// conn returns the transaction carried by ctx or the pool.
+func (r *UserTeamRepository) conn(ctx context.Context) db.Querier {
+	return db.Conn(ctx, r.db)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/tenant"
+)
+
+// bootstrapTokenID identifies the configured bootstrap token in identities.
+const bootstrapTokenID = "bootstrap"
+
+type AuthService struct {
+	tokenRepo     TokenRepository
+	userRepo      UserTeamRepository
+	bootstrapHash string
+	jwt           JWTVerifier
+}
+
+type AuthOption func(*AuthService)
+
+// WithJWTVerifier makes Authenticate accept JWTs issued by the company SSO
+// besides the service's own API tokens.
+func WithJWTVerifier(v JWTVerifier) AuthOption {
+	return func(s *AuthService) {
+		s.jwt = v
+	}
+}
+
+// NewAuthService creates the token service. A non-empty bootstrapToken is
+// accepted as an admin token without being stored, so that the first real
+// tokens can be issued.
+func NewAuthService(tr TokenRepository, ur UserTeamRepository, bootstrapToken string, opts ...AuthOption) *AuthService {
+	s := &AuthService{
+		tokenRepo: tr,
+		userRepo:  ur,
+	}
+	if bootstrapToken != "" {
+		s.bootstrapHash = auth.HashToken(bootstrapToken)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Issue creates a token in the caller's tenant and returns its secret
+// together with the stored record. The secret is not kept and cannot be
+// retrieved later. User tokens must belong to an existing user.
+func (s *AuthService) Issue(ctx context.Context, req domain.TokenIssue) (string, domain.APIToken, error) {
+	if !req.Scope.IsValid() {
+		return "", domain.APIToken{}, domain.ErrInvalidScope
+	}
+
+	if req.UserID != "" {
+		user, err := s.userRepo.GetUserByID(ctx, req.UserID)
+		if err != nil {
+			return "", domain.APIToken{}, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return "", domain.APIToken{}, domain.ErrNotFound
+		}
+	} else if req.Scope == domain.ScopeUser {
+		return "", domain.APIToken{}, domain.ErrTokenNeedsUser
+	}
+
+	secret, err := auth.NewToken()
+	if err != nil {
+		return "", domain.APIToken{}, err
+	}
+	id, err := auth.NewTokenID()
+	if err != nil {
+		return "", domain.APIToken{}, err
+	}
+
+	token := domain.APIToken{
+		ID:        id,
+		TenantID:  tenant.FromContext(ctx),
+		Name:      req.Name,
+		Hash:      auth.HashToken(secret),
+		Scope:     req.Scope,
+		UserID:    req.UserID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.tokenRepo.CreateToken(ctx, token); err != nil {
+		return "", domain.APIToken{}, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return secret, token, nil
+}
+
+// Revoke disables the token. Revoking a revoked token is a no-op.
+func (s *AuthService) Revoke(ctx context.Context, id string) (domain.APIToken, error) {
+	if err := s.tokenRepo.RevokeToken(ctx, id, time.Now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.APIToken{}, err
+		}
+		return domain.APIToken{}, fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	token, err := s.tokenRepo.GetTokenByID(ctx, id)
+	if err != nil {
+		return domain.APIToken{}, fmt.Errorf("failed to get token: %w", err)
+	}
+	if token == nil {
+		return domain.APIToken{}, domain.ErrNotFound
+	}
+
+	return *token, nil
+}
+
+// Authenticate resolves a bearer token secret to the caller identity. Unknown
+// and revoked tokens yield ErrUnauthorized. When a JWT verifier is set,
+// tokens shaped like a JWT are checked by it instead.
+func (s *AuthService) Authenticate(ctx context.Context, secret string) (auth.Identity, error) {
+	if s.jwt != nil && auth.IsJWT(secret) {
+		return s.jwt.Verify(ctx, secret)
+	}
+
+	hash := auth.HashToken(secret)
+
+	if s.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(s.bootstrapHash)) == 1 {
+		return auth.Identity{TokenID: bootstrapTokenID, Scope: domain.ScopeAdmin}, nil
+	}
+
+	token, err := s.tokenRepo.GetTokenByHash(ctx, hash)
+	if err != nil {
+		return auth.Identity{}, fmt.Errorf("failed to get token: %w", err)
+	}
+	if token == nil || token.IsRevoked() {
+		return auth.Identity{}, domain.ErrUnauthorized
+	}
+
+	return auth.Identity{
+		TokenID:  token.ID,
+		Scope:    token.Scope,
+		UserID:   token.UserID,
+		TenantID: token.TenantID,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+)
+
+// caller returns the identity the request was authenticated as. Calls without
+// one are refused rather than treated as trusted.
+func caller(ctx context.Context) (auth.Identity, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return auth.Identity{}, domain.ErrForbidden
+	}
+	return id, nil
+}
+
+// authorizeCreate allows admins and users opening a PR on their own behalf.
+func authorizeCreate(ctx context.Context, request domain.PullRequestCreate) error {
+	id, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if id.IsAdmin() || (id.UserID != "" && id.UserID == request.AuthorID) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// authorizeMerge allows the PR author and admins.
+func authorizeMerge(ctx context.Context, pr *domain.PullRequest) error {
+	id, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if id.IsAdmin() || (id.UserID != "" && id.UserID == pr.AuthorID) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// authorizeReassign allows the reviewer being replaced, the PR author, a lead
+// of the author's team and admins.
+func authorizeReassign(ctx context.Context, pr *domain.PullRequest, oldReviewerID string, team *domain.Team) error {
+	id, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if id.IsAdmin() {
+		return nil
+	}
+	if id.UserID == "" {
+		return domain.ErrForbidden
+	}
+	if id.UserID == oldReviewerID || id.UserID == pr.AuthorID {
+		return nil
+	}
+	for _, m := range team.Members {
+		if m.ID == id.UserID && m.Role == domain.RoleLead {
+			return nil
+		}
+	}
+	return domain.ErrForbidden
+}
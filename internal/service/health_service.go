@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"pr-service/internal/domain"
+)
+
+// readinessTimeout bounds each readiness check, so a hung database does not
+// hang the probe.
+const readinessTimeout = 2 * time.Second
+
+const (
+	checkDatabase   = "database"
+	checkMigrations = "migrations"
+	checkShutdown   = "shutdown"
+)
+
+type HealthService struct {
+	db       Pinger
+	schema   SchemaReader
+	want     uint
+	draining atomic.Bool
+}
+
+// NewHealthService creates the readiness checker. wantSchema is the schema
+// version the build needs; 0 skips the migration check.
+func NewHealthService(db Pinger, schema SchemaReader, wantSchema uint) *HealthService {
+	return &HealthService{db: db, schema: schema, want: wantSchema}
+}
+
+// Drain makes the instance report not ready from now on, so load balancers
+// stop sending traffic before the server shuts down.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness runs the checks. The instance is ready when all of them pass.
+func (s *HealthService) Readiness(ctx context.Context) domain.Readiness {
+	checks := []domain.HealthCheck{
+		s.checkDatabase(ctx),
+	}
+	if s.want > 0 {
+		checks = append(checks, s.checkMigrations(ctx))
+	}
+	shutdown := domain.HealthCheck{Name: checkShutdown, OK: true}
+	if s.draining.Load() {
+		shutdown = domain.HealthCheck{Name: checkShutdown, Detail: "draining"}
+	}
+	checks = append(checks, shutdown)
+
+	ready := true
+	for _, c := range checks {
+		ready = ready && c.OK
+	}
+
+	return domain.Readiness{Ready: ready, Checks: checks}
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) domain.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return domain.HealthCheck{Name: checkDatabase, Detail: err.Error()}
+	}
+	return domain.HealthCheck{Name: checkDatabase, OK: true}
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) domain.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	version, dirty, err := s.schema.Version(ctx)
+	switch {
+	case err != nil:
+		return domain.HealthCheck{Name: checkMigrations, Detail: err.Error()}
+	case dirty:
+		return domain.HealthCheck{Name: checkMigrations, Detail: fmt.Sprintf("migration %d failed, schema is dirty", version)}
+	case version < s.want:
+		return domain.HealthCheck{Name: checkMigrations, Detail: fmt.Sprintf("pending migrations: at %d, want %d", version, s.want)}
+	default:
+		return domain.HealthCheck{Name: checkMigrations, OK: true, Detail: fmt.Sprintf("version %d", version)}
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"pr-service/internal/auth"
 	"pr-service/internal/domain"
+	"time"
 )
 
 type UserTeamRepository interface {
@@ -12,13 +14,72 @@ type UserTeamRepository interface {
 	GetByName(ctx context.Context, name string) (*domain.Team, error)
 	SetUserActive(ctx context.Context, req domain.ActivateUserRequest) error
 	DeactivateByTeam(ctx context.Context, teamName string) error
+	AddMembers(ctx context.Context, teamName string, members []domain.User) error
+	SetUserTeam(ctx context.Context, userID, teamName string) error
+	RemoveFromTeam(ctx context.Context, teamName, userID string) error
+	SetRole(ctx context.Context, teamName, userID string, role domain.Role) error
+	UpdateUsername(ctx context.Context, userID, username string) error
+	ArchiveTeam(ctx context.Context, name string, at time.Time) error
+	DeleteTeam(ctx context.Context, name string) error
+	ListTeams(ctx context.Context, includeArchived bool, page domain.Page) ([]domain.TeamSummary, int, error)
+	ListTeamsWithMembers(ctx context.Context) ([]domain.Team, error)
+	SearchUsers(ctx context.Context, filter domain.UserFilter, page domain.Page) ([]domain.User, int, error)
+	SetParent(ctx context.Context, name, parent string) error
+	GetTree(ctx context.Context, root string, includeArchived bool) ([]domain.TeamNode, error)
+	GetSiblingMembers(ctx context.Context, teamName string) ([]domain.User, error)
 }
 
 type PRRepository interface {
-	Create(ctx context.Context, pr domain.PullRequest) error
+	Create(ctx context.Context, pr domain.PullRequest, trace domain.AssignmentTrace) error
 	GetByID(ctx context.Context, id string) (*domain.PullRequest, error)
-	UpdatePR(ctx context.Context, request domain.PullRequest) error
-	ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error
+	GetByIDForUpdate(ctx context.Context, id string) (*domain.PullRequest, error)
+	UpdatePR(ctx context.Context, request domain.PullRequest, expectedVersion int) (int, error)
+	ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string, expectedVersion int, trace domain.AssignmentTrace) error
 	GetByReviewer(ctx context.Context, reviewerID string) ([]domain.PullRequest, error)
 	GetAllPRs(ctx context.Context) ([]domain.PullRequest, error)
+	GetRecentByAuthor(ctx context.Context, authorID string, limit int) ([]domain.PullRequest, error)
+	GetAssignments(ctx context.Context, prID string) ([]domain.AssignmentTrace, error)
+	CountOpenByTeam(ctx context.Context, teamName string) (int, error)
+}
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token domain.APIToken) error
+	GetTokenByHash(ctx context.Context, hash string) (*domain.APIToken, error)
+	GetTokenByID(ctx context.Context, id string) (*domain.APIToken, error)
+	RevokeToken(ctx context.Context, id string, at time.Time) error
+}
+
+// JWTVerifier validates an externally issued JWT and maps its claims to an
+// identity.
+type JWTVerifier interface {
+	Verify(ctx context.Context, token string) (auth.Identity, error)
+}
+
+// PRMetrics counts PR events. Events are reported once the service call
+// succeeds; a transaction of the caller may still roll them back.
+type PRMetrics interface {
+	PRCreated(ctx context.Context)
+	PRMerged(ctx context.Context)
+	ReviewerReassigned(ctx context.Context)
+	NoCandidate(ctx context.Context)
+}
+
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// SchemaReader reports the applied schema version and whether the last
+// migration failed halfway.
+type SchemaReader interface {
+	Version(ctx context.Context) (uint, bool, error)
+}
+
+// TxManager runs fn in a single transaction that repositories pick up from
+// the context passed to fn.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ReviewReassigner interface {
+	Reassign(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (*domain.PullRequest, error)
 }
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"pr-service/internal/domain"
+)
+
+// PairingDiversity configures reviewer selection that prefers people who have
+// not reviewed the author's recent pull requests.
+type PairingDiversity struct {
+	// Window is how many of the author's most recent PRs are inspected.
+	Window int
+	// Decay scales the penalty of each older PR: the latest PR counts 1,
+	// the one before it Decay, then Decay^2 and so on.
+	Decay float64
+}
+
+// pairingWeights returns a selection weight per candidate. A candidate who
+// never reviewed the author's recent PRs gets weight 1, every recent review
+// lowers it.
+func (s *PRService) pairingWeights(ctx context.Context, authorID string, candidates []domain.User) (map[string]float64, error) {
+	history, err := s.prRepo.GetRecentByAuthor(ctx, authorID, s.diversity.Window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author history: %w", err)
+	}
+
+	penalties := make(map[string]float64, len(candidates))
+	for age, pr := range history {
+		factor := math.Pow(s.diversity.Decay, float64(age))
+		for _, reviewerID := range pr.AssignedReviewers {
+			penalties[reviewerID] += factor
+		}
+	}
+
+	weights := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		weights[c.ID] = 1 / (1 + penalties[c.ID])
+	}
+
+	return weights, nil
+}
@@ -2,63 +2,187 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"pr-service/internal/domain"
+	"pr-service/internal/logger"
+	"pr-service/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultReviewerCount is how many reviewers a new PR gets unless
+// WithReviewerCount says otherwise.
+const defaultReviewerCount = 2
+
+// Span attributes of PRService spans.
+const (
+	attrPRID       = attribute.Key("pr.id")
+	attrReviewerID = attribute.Key("pr.reviewer_id")
 )
 
 type PRService struct {
 	prRepo   PRRepository
 	userRepo UserTeamRepository
+	tx       TxManager
+
+	diversity       *PairingDiversity
+	siblingFallback bool
+	rnd             *rand.Rand
+	rndMu           sync.Mutex
+	metrics         PRMetrics
+	reviewerCount   int
+}
+
+type PROption func(*PRService)
+
+// WithPairingDiversity switches reviewer selection from uniform sampling to
+// weighted sampling that down-weights the author's recent reviewers.
+func WithPairingDiversity(cfg PairingDiversity) PROption {
+	return func(s *PRService) {
+		s.diversity = &cfg
+	}
+}
+
+// WithSiblingFallback lets selection fall back to the members of sibling
+// squads (teams sharing the same parent) when the author's team has no
+// eligible reviewers.
+func WithSiblingFallback() PROption {
+	return func(s *PRService) {
+		s.siblingFallback = true
+	}
+}
+
+// WithRandSource sets the source every selection seed is drawn from.
+// Pass a fixed-seed source to make assignments reproducible.
+func WithRandSource(src rand.Source) PROption {
+	return func(s *PRService) {
+		s.rnd = rand.New(src)
+	}
+}
+
+// WithReviewerCount sets how many reviewers a new PR gets; the default is
+// two. A requested lead comes on top of them.
+func WithReviewerCount(n int) PROption {
+	return func(s *PRService) {
+		s.reviewerCount = n
+	}
+}
+
+// WithMetrics reports created and merged PRs, reassignments and NO_CANDIDATE
+// failures to m.
+func WithMetrics(m PRMetrics) PROption {
+	return func(s *PRService) {
+		s.metrics = m
+	}
 }
 
-func NewPRService(pr PRRepository, ur UserTeamRepository) *PRService {
-	return &PRService{
+// noMetrics is used when WithMetrics is not given.
+type noMetrics struct{}
+
+func (noMetrics) PRCreated(context.Context)          {}
+func (noMetrics) PRMerged(context.Context)           {}
+func (noMetrics) ReviewerReassigned(context.Context) {}
+func (noMetrics) NoCandidate(context.Context)        {}
+
+func NewPRService(pr PRRepository, ur UserTeamRepository, tx TxManager, opts ...PROption) *PRService {
+	s := &PRService{
 		prRepo:   pr,
 		userRepo: ur,
+		tx:       tx,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		metrics:  noMetrics{},
+
+		reviewerCount: defaultReviewerCount,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-func (s *PRService) Create(ctx context.Context, request domain.PullRequestCreate) (domain.PullRequest, error) {
-	existsPr, err := s.prRepo.GetByID(ctx, request.ID)
-	if err != nil {
-		return domain.PullRequest{}, fmt.Errorf("failed to get exists pr")
+// Create relies on the primary key to reject duplicate IDs: the repository
+// reports a unique violation as ErrPRAlreadyExists, which also covers two
+// concurrent requests with the same ID. Only admins may open a PR on behalf
+// of another author; anyone else gets ErrForbidden.
+func (s *PRService) Create(ctx context.Context, request domain.PullRequestCreate) (_ domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.Create", attrPRID.String(request.ID))
+	defer func() { tracing.End(span, err) }()
+
+	if err := authorizeCreate(ctx, request); err != nil {
+		return domain.PullRequest{}, err
 	}
-	if existsPr != nil {
-		return domain.PullRequest{}, domain.ErrPRAlreadyExists
+
+	var pr domain.PullRequest
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.create(ctx, request)
+		return err
+	})
+	if err != nil {
+		return domain.PullRequest{}, err
 	}
 
+	s.metrics.PRCreated(ctx)
+
+	return pr, nil
+}
+
+func (s *PRService) create(ctx context.Context, request domain.PullRequestCreate) (domain.PullRequest, error) {
 	author, err := s.userRepo.GetUserByID(ctx, request.AuthorID)
 	if err != nil || author == nil {
 		return domain.PullRequest{}, domain.ErrNotFound
 	}
 
 	team, err := s.userRepo.GetByName(ctx, author.TeamName)
-	if err != nil || team == nil {
-		return domain.PullRequest{}, fmt.Errorf("author's team not found")
+	if err != nil {
+		return domain.PullRequest{}, fmt.Errorf("failed to get author's team: %w", err)
+	}
+	if team == nil {
+		// The author was removed from their team.
+		return domain.PullRequest{}, domain.ErrNotFound
+	}
+	if team.IsArchived() {
+		return domain.PullRequest{}, domain.ErrTeamArchived
 	}
 
-	reviewers := s.selectReviewers(team.Members, request.AuthorID, 2)
+	trace, err := s.selectTeamReviewers(ctx, team, request.AuthorID, nil, s.reviewerCount, request.RequestLead)
+	if err != nil {
+		return domain.PullRequest{}, fmt.Errorf("failed to select reviewers: %w", err)
+	}
+	trace.PRID = request.ID
+	trace.Kind = domain.AssignmentCreate
 
 	pr := domain.PullRequest{
 		ID:                request.ID,
 		Name:              request.Name,
 		AuthorID:          request.AuthorID,
 		Status:            domain.PRStatusOpen,
-		AssignedReviewers: reviewers,
+		AssignedReviewers: trace.Picked,
 		CreatedAt:         time.Now(),
+		Version:           1,
 	}
 
-	if err := s.prRepo.Create(ctx, pr); err != nil {
+	if err := s.prRepo.Create(ctx, pr, trace); err != nil {
+		if errors.Is(err, domain.ErrPRAlreadyExists) {
+			return domain.PullRequest{}, err
+		}
 		return domain.PullRequest{}, fmt.Errorf("failed to create PR: %w", err)
 	}
 
 	return pr, nil
 }
 
-func (s *PRService) Get(ctx context.Context, id string) (*domain.PullRequest, error) {
+func (s *PRService) Get(ctx context.Context, id string) (_ *domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.Get", attrPRID.String(id))
+	defer func() { tracing.End(span, err) }()
+
 	pr, err := s.prRepo.GetByID(ctx, id)
 
 	if err != nil {
@@ -72,128 +196,163 @@ func (s *PRService) Get(ctx context.Context, id string) (*domain.PullRequest, er
 	return pr, nil
 }
 
-func (s *PRService) Merge(ctx context.Context, id string) (*domain.PullRequest, error) {
-	pr, err := s.Get(ctx, id)
-	if err != nil {
-		return nil, err
-	}
+// Merge marks the PR as merged. Only the author or an admin may merge;
+// anyone else gets ErrForbidden. A non-zero expectedVersion must match the
+// current version of the PR, otherwise ErrVersionMismatch is returned. The
+// PR row is locked while merging, so of concurrent merges only the first
+// one changes the PR and is counted; the others see it already merged.
+func (s *PRService) Merge(ctx context.Context, id string, expectedVersion int) (_ *domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.Merge", attrPRID.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	var (
+		pr     *domain.PullRequest
+		merged bool
+	)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get PR: %w", err)
+		}
+		if pr == nil {
+			return domain.ErrNotFound
+		}
 
-	if pr.Status == domain.PRStatusMerged {
-		return pr, nil
-	}
+		if err := authorizeMerge(ctx, pr); err != nil {
+			return err
+		}
+
+		if expectedVersion != 0 && pr.Version != expectedVersion {
+			return domain.ErrVersionMismatch
+		}
+
+		if pr.Status == domain.PRStatusMerged {
+			return nil
+		}
+
+		if err = pr.Merge(); err != nil {
+			return err
+		}
+
+		pr.Version, err = s.prRepo.UpdatePR(ctx, *pr, expectedVersion)
+		if err != nil {
+			if errors.Is(err, domain.ErrVersionMismatch) {
+				return err
+			}
+			return fmt.Errorf("failed to merge PR: %w", err)
+		}
 
-	if err = pr.Merge(); err != nil {
+		merged = true
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if err = s.prRepo.UpdatePR(ctx, *pr); err != nil {
-		return nil, fmt.Errorf("failed to merge PR: %w", err)
+	if merged {
+		s.metrics.PRMerged(ctx)
 	}
 
 	return pr, nil
 }
 
-func (s *PRService) Reassign(ctx context.Context, prID, oldReviewerID string) (*domain.PullRequest, error) {
-	pr, err := s.Get(ctx, prID)
+// maxReassignAttempts bounds how many times Reassign re-reads the PR and picks
+// again after the repository reports that a concurrent request took the
+// chosen reviewer.
+const maxReassignAttempts = 3
+
+// Reassign replaces oldReviewerID with another member of the author's team.
+// The caller must be the reviewer being replaced, the author, a lead of the
+// author's team or an admin, otherwise ErrForbidden is returned. A non-zero
+// expectedVersion must match the current version of the PR.
+func (s *PRService) Reassign(ctx context.Context, prID, oldReviewerID string, expectedVersion int) (_ *domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.Reassign", attrPRID.String(prID), attrReviewerID.String(oldReviewerID))
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; attempt < maxReassignAttempts; attempt++ {
+		err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			return s.reassignOnce(ctx, prID, oldReviewerID, expectedVersion)
+		})
+		if !errors.Is(err, domain.ErrReviewerConflict) {
+			break
+		}
+		logger.FromContext(ctx).Debug().Str("pr_id", prID).Int("attempt", attempt+1).
+			Msg("picked reviewer was taken concurrently, retrying")
+	}
+	if errors.Is(err, domain.ErrNoCandidate) {
+		s.metrics.NoCandidate(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if !pr.IsPROpen() {
-		return nil, domain.ErrPRMerged
-	}
+	s.metrics.ReviewerReassigned(ctx)
 
-	assigned := false
-	for _, reviewerID := range pr.AssignedReviewers {
-		if reviewerID == oldReviewerID {
-			assigned = true
-			break
-		}
-	}
-	if !assigned {
-		return nil, domain.ErrNotAssigned
+	return s.Get(ctx, prID)
+}
+
+func (s *PRService) reassignOnce(ctx context.Context, prID, oldReviewerID string, expectedVersion int) error {
+	pr, err := s.Get(ctx, prID)
+	if err != nil {
+		return err
 	}
 
 	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
 	if err != nil || author == nil {
-		return nil, fmt.Errorf("author not found")
+		return fmt.Errorf("author not found")
 	}
 
 	team, err := s.userRepo.GetByName(ctx, author.TeamName)
 	if err != nil || team == nil {
-		return nil, fmt.Errorf("team not found")
+		return fmt.Errorf("team not found")
 	}
 
-	newReviewerID, err := s.selectNewReviewer(team.Members, pr.AuthorID, pr.AssignedReviewers)
-	if err != nil {
-		return nil, err
+	if err := authorizeReassign(ctx, pr, oldReviewerID, team); err != nil {
+		return err
 	}
 
-	if err := s.prRepo.ReassignReviewer(ctx, prID, oldReviewerID, newReviewerID); err != nil {
-		return nil, fmt.Errorf("failed to reassign reviewer: %w", err)
+	if expectedVersion != 0 && pr.Version != expectedVersion {
+		return domain.ErrVersionMismatch
 	}
 
-	for i, reviewerID := range pr.AssignedReviewers {
-		if reviewerID == oldReviewerID {
-			pr.AssignedReviewers[i] = newReviewerID
-			break
-		}
-	}
-
-	return pr, nil
-}
-
-func (s *PRService) selectNewReviewer(members []domain.User, authorID string, currentReviewers []string) (string, error) {
-	reviewerMap := make(map[string]bool)
-	for _, rid := range currentReviewers {
-		reviewerMap[rid] = true
+	if !pr.IsPROpen() {
+		return domain.ErrPRMerged
 	}
 
-	var candidates []domain.User
-	for _, member := range members {
-		if member.IsActive && member.ID != authorID && !reviewerMap[member.ID] {
-			candidates = append(candidates, member)
+	assigned := false
+	for _, reviewerID := range pr.AssignedReviewers {
+		if reviewerID == oldReviewerID {
+			assigned = true
+			break
 		}
 	}
-
-	if len(candidates) == 0 {
-		return "", domain.ErrNoCandidate
+	if !assigned {
+		return domain.ErrNotAssigned
 	}
 
-	idx := rand.Intn(len(candidates))
-	return candidates[idx].ID, nil
-}
-
-func (s *PRService) selectReviewers(members []domain.User, authorID string, maxCount int) []string {
-	var candidates []domain.User
-	for _, member := range members {
-		if member.IsActive && member.ID != authorID {
-			candidates = append(candidates, member)
-		}
+	trace, err := s.selectTeamReviewers(ctx, team, pr.AuthorID, pr.AssignedReviewers, 1, false)
+	if err != nil {
+		return fmt.Errorf("failed to select reviewer: %w", err)
 	}
-
-	if len(candidates) == 0 {
-		return []string{}
+	if len(trace.Picked) == 0 {
+		return domain.ErrNoCandidate
 	}
+	trace.PRID = prID
+	trace.Kind = domain.AssignmentReassign
+	trace.ReplacedReviewerID = oldReviewerID
 
-	count := maxCount
-	if len(candidates) < maxCount {
-		count = len(candidates)
+	if err := s.prRepo.ReassignReviewer(ctx, prID, oldReviewerID, trace.Picked[0], expectedVersion, trace); err != nil {
+		return fmt.Errorf("failed to reassign reviewer: %w", err)
 	}
 
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
-
-	reviewers := make([]string, count)
-	for i := 0; i < count; i++ {
-		reviewers[i] = candidates[i].ID
-	}
-
-	return reviewers
+	return nil
 }
 
-func (s *PRService) GetByReviewer(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
+func (s *PRService) GetByReviewer(ctx context.Context, reviewerID string) (_ []domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetByReviewer", attrReviewerID.String(reviewerID))
+	defer func() { tracing.End(span, err) }()
+
 	user, err := s.userRepo.GetUserByID(ctx, reviewerID)
 	if err != nil || user == nil {
 		return nil, domain.ErrNotFound
@@ -207,7 +366,26 @@ func (s *PRService) GetByReviewer(ctx context.Context, reviewerID string) ([]dom
 	return prs, nil
 }
 
-func (s *PRService) GetAllPRs(ctx context.Context) ([]domain.PullRequest, error) {
+func (s *PRService) ExplainAssignment(ctx context.Context, prID string) (_ []domain.AssignmentTrace, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.ExplainAssignment", attrPRID.String(prID))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.Get(ctx, prID); err != nil {
+		return nil, err
+	}
+
+	traces, err := s.prRepo.GetAssignments(ctx, prID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignments: %w", err)
+	}
+
+	return traces, nil
+}
+
+func (s *PRService) GetAllPRs(ctx context.Context) (_ []domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetAllPRs")
+	defer func() { tracing.End(span, err) }()
+
 	prs, err := s.prRepo.GetAllPRs(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all PRs: %w", err)
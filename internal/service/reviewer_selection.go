@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"pr-service/internal/domain"
+)
+
+// selectReviewers picks up to count reviewers among members, skipping the
+// author, observers, inactive users and the already assigned ones. With lead
+// set it also makes sure an eligible team lead is among the picks, adding one
+// when none was drawn. Every call draws a fresh seed from the service source
+// and records it in the returned trace, so the pick can be reproduced from the
+// trace alone.
+func (s *PRService) selectReviewers(ctx context.Context, members []domain.User, authorID string, assigned []string, count int, lead bool) (domain.AssignmentTrace, error) {
+	trace := domain.AssignmentTrace{
+		Strategy: domain.StrategyRandom,
+		Seed:     s.nextSeed(),
+		Picked:   []string{},
+	}
+	if s.diversity != nil {
+		trace.Strategy = domain.StrategyDiversity
+	}
+
+	assignedSet := make(map[string]bool, len(assigned))
+	for _, id := range assigned {
+		assignedSet[id] = true
+	}
+
+	sorted := make([]domain.User, len(members))
+	copy(sorted, members)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var candidates []domain.User
+	for _, member := range sorted {
+		reason := exclusionReason(member, authorID, assignedSet)
+		trace.Candidates = append(trace.Candidates, domain.AssignmentCandidate{
+			UserID:   member.ID,
+			Excluded: reason,
+		})
+		if reason == "" {
+			candidates = append(candidates, member)
+		}
+	}
+
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	if count == 0 {
+		return trace, nil
+	}
+
+	weights := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		weights[c.ID] = 1
+	}
+	if s.diversity != nil {
+		var err error
+		weights, err = s.pairingWeights(ctx, authorID, candidates)
+		if err != nil {
+			return domain.AssignmentTrace{}, err
+		}
+	}
+
+	for i := range trace.Candidates {
+		if trace.Candidates[i].IsEligible() {
+			trace.Candidates[i].Weight = weights[trace.Candidates[i].UserID]
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(trace.Seed))
+	trace.Picked = weightedSample(rnd, candidates, weights, count)
+
+	if lead {
+		trace.LeadID = pickLead(rnd, candidates, trace.Picked)
+		if trace.LeadID != "" && !containsID(trace.Picked, trace.LeadID) {
+			trace.Picked = append(trace.Picked, trace.LeadID)
+		}
+	}
+
+	return trace, nil
+}
+
+// pickLead returns a lead that is already in picked, or else a random lead
+// among the candidates. It returns "" when the candidates have no lead.
+func pickLead(rnd *rand.Rand, candidates []domain.User, picked []string) string {
+	var leads []string
+	for _, c := range candidates {
+		if c.Role != domain.RoleLead {
+			continue
+		}
+		if containsID(picked, c.ID) {
+			return c.ID
+		}
+		leads = append(leads, c.ID)
+	}
+
+	if len(leads) == 0 {
+		return ""
+	}
+	return leads[rnd.Intn(len(leads))]
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// selectTeamReviewers runs selectReviewers over the team's members. When
+// nobody is eligible and sibling fallback is enabled, it repeats the
+// selection over the members of the team's sibling squads and records those
+// squads in the trace.
+func (s *PRService) selectTeamReviewers(ctx context.Context, team *domain.Team, authorID string, assigned []string, count int, lead bool) (domain.AssignmentTrace, error) {
+	trace, err := s.selectReviewers(ctx, team.Members, authorID, assigned, count, lead)
+	if err != nil {
+		return domain.AssignmentTrace{}, err
+	}
+	if len(trace.Picked) > 0 || !s.siblingFallback || team.ParentName == "" {
+		return trace, nil
+	}
+
+	siblings, err := s.userRepo.GetSiblingMembers(ctx, team.Name)
+	if err != nil {
+		return domain.AssignmentTrace{}, fmt.Errorf("failed to get sibling members: %w", err)
+	}
+	if len(siblings) == 0 {
+		return trace, nil
+	}
+
+	fallback, err := s.selectReviewers(ctx, siblings, authorID, assigned, count, lead)
+	if err != nil {
+		return domain.AssignmentTrace{}, err
+	}
+	if len(fallback.Picked) == 0 {
+		return trace, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, u := range siblings {
+		if !seen[u.TeamName] {
+			seen[u.TeamName] = true
+			fallback.FallbackTeams = append(fallback.FallbackTeams, u.TeamName)
+		}
+	}
+	sort.Strings(fallback.FallbackTeams)
+
+	return fallback, nil
+}
+
+func exclusionReason(member domain.User, authorID string, assigned map[string]bool) domain.ExclusionReason {
+	switch {
+	case member.ID == authorID:
+		return domain.ExcludedAuthor
+	case member.Role == domain.RoleObserver:
+		return domain.ExcludedObserver
+	case !member.IsActive:
+		return domain.ExcludedInactive
+	case assigned[member.ID]:
+		return domain.ExcludedAlreadyAssigned
+	default:
+		return ""
+	}
+}
+
+func (s *PRService) nextSeed() int64 {
+	s.rndMu.Lock()
+	defer s.rndMu.Unlock()
+
+	return s.rnd.Int63()
+}
+
+// weightedSample picks up to count distinct candidates, each draw proportional
+// to the remaining weights. With equal weights this is a uniform sample.
+func weightedSample(rnd *rand.Rand, candidates []domain.User, weights map[string]float64, count int) []string {
+	pool := make([]domain.User, len(candidates))
+	copy(pool, candidates)
+
+	picked := make([]string, 0, count)
+	for len(picked) < count && len(pool) > 0 {
+		total := 0.0
+		for _, c := range pool {
+			total += weights[c.ID]
+		}
+
+		target := rnd.Float64() * total
+		idx := len(pool) - 1
+		for i, c := range pool {
+			target -= weights[c.ID]
+			if target < 0 {
+				idx = i
+				break
+			}
+		}
+
+		picked = append(picked, pool[idx].ID)
+		pool = append(pool[:idx], pool[idx+1:]...)
+	}
+
+	return picked
+}
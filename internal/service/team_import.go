@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pr-service/internal/domain"
+)
+
+// errDryRun rolls back the transaction of a dry-run import.
+var errDryRun = errors.New("dry run")
+
+// Import brings every team in teams to the state described there, in one
+// transaction: missing teams are created, each membership is synced as in
+// Sync and teams are moved under the given parent, or made roots when it is
+// empty; teams with KeepParent keep their current one. Teams not mentioned
+// are left alone. Problems found before applying
+// anything are returned as domain.ImportIssues. With opts.DryRun the result
+// shows the changes but nothing is kept; reviews are not reassigned then,
+// the open PRs that would be are reported with their current reviewers.
+func (s *TeamService) Import(ctx context.Context, teams []domain.ImportTeam, opts domain.ImportOptions) (domain.ImportResult, error) {
+	result := domain.ImportResult{DryRun: opts.DryRun}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.checkImport(ctx, teams)
+		if err != nil {
+			return err
+		}
+
+		// Teams are created without a parent first, so that a parent may
+		// come later in the file than its children.
+		for _, t := range teams {
+			team := t.Team
+			team.ParentName = ""
+
+			// Members moved in from another team hand over their reviews as
+			// well. They are looked up before the sync puts them in this team.
+			var handOver []string
+			if opts.ReassignReviews && opts.DryRun {
+				if handOver, err = s.otherTeamMembers(ctx, t.Name, t.Members, true); err != nil {
+					return fmt.Errorf("team %s: %w", t.Name, err)
+				}
+			}
+
+			// PRService reports reassignments to metrics right away, which
+			// the rollback of a dry run would not undo.
+			diff, reassigned, err := s.Sync(ctx, team, opts.ReassignReviews && !opts.DryRun)
+			if err != nil {
+				return fmt.Errorf("team %s: %w", t.Name, err)
+			}
+			if opts.ReassignReviews && opts.DryRun {
+				for _, u := range diff.Removed {
+					handOver = append(handOver, u.ID)
+				}
+				if reassigned, err = s.openReviewsOf(ctx, handOver); err != nil {
+					return fmt.Errorf("team %s: %w", t.Name, err)
+				}
+			}
+
+			var oldParent string
+			if cur := current[t.Name]; cur != nil {
+				oldParent = cur.ParentName
+			}
+			diff.Team.ParentName = oldParent
+
+			result.Teams = append(result.Teams, domain.TeamImport{
+				Diff:       diff,
+				OldParent:  oldParent,
+				Reassigned: reassigned,
+			})
+		}
+
+		for i, t := range teams {
+			if t.KeepParent || t.ParentName == result.Teams[i].OldParent {
+				continue
+			}
+
+			if err := s.teamRepo.SetParent(ctx, t.Name, t.ParentName); err != nil {
+				if errors.Is(err, domain.ErrTeamCycle) {
+					return domain.ImportIssues{{Line: t.Line, Team: t.Name, Message: domain.ErrTeamCycle.Error()}}
+				}
+				return fmt.Errorf("failed to set parent of %s: %w", t.Name, err)
+			}
+			result.Teams[i].Diff.Team.ParentName = t.ParentName
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return domain.ImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// openReviewsOf returns the open PRs reviewed by the users, the ones a sync
+// with reassignReviews hands over for removed and moved members.
+func (s *TeamService) openReviewsOf(ctx context.Context, userIDs []string) ([]domain.PullRequest, error) {
+	var open []domain.PullRequest
+	for _, id := range userIDs {
+		prs, err := s.prRepo.GetByReviewer(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PRs by reviewer: %w", err)
+		}
+		for _, pr := range prs {
+			if pr.IsPROpen() {
+				open = mergePRs(open, []domain.PullRequest{pr})
+			}
+		}
+	}
+	return open, nil
+}
+
+// checkImport reports teams that cannot be imported and returns the current
+// state of the others, nil for teams that do not exist yet.
+func (s *TeamService) checkImport(ctx context.Context, teams []domain.ImportTeam) (map[string]*domain.Team, error) {
+	var issues domain.ImportIssues
+
+	current := make(map[string]*domain.Team, len(teams))
+	for _, t := range teams {
+		team, err := s.teamRepo.GetByName(ctx, t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team: %w", err)
+		}
+		if team != nil && team.IsArchived() {
+			issues = append(issues, domain.ImportIssue{Line: t.Line, Team: t.Name, Message: domain.ErrTeamArchived.Error()})
+		}
+		current[t.Name] = team
+	}
+
+	for _, t := range teams {
+		if t.KeepParent || t.ParentName == "" {
+			continue
+		}
+		if cur := current[t.Name]; cur != nil && cur.ParentName == t.ParentName {
+			continue
+		}
+		if _, inFile := current[t.ParentName]; inFile {
+			continue
+		}
+
+		if _, err := s.getWritable(ctx, t.ParentName); err != nil {
+			if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrTeamArchived) {
+				issues = append(issues, domain.ImportIssue{
+					Line:    t.Line,
+					Team:    t.Name,
+					Message: fmt.Sprintf("parent %s: %v", t.ParentName, err),
+				})
+				continue
+			}
+			return nil, err
+		}
+	}
+
+	if len(issues) > 0 {
+		return nil, issues
+	}
+	return current, nil
+}
+
+// Export returns every team that is not archived with its members, parents
+// before their children, in the shape Import accepts.
+func (s *TeamService) Export(ctx context.Context) ([]domain.Team, error) {
+	teams, err := s.teamRepo.ListTeamsWithMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	// Teams come ordered by name; emit each one after its parent. A parent
+	// that is archived is not exported, so such a team is exported as a root:
+	// naming the parent would make the file fail to import.
+	byName := make(map[string]bool, len(teams))
+	children := make(map[string][]domain.Team)
+	for _, t := range teams {
+		byName[t.Name] = true
+	}
+
+	var roots []domain.Team
+	for _, t := range teams {
+		if t.ParentName != "" && byName[t.ParentName] {
+			children[t.ParentName] = append(children[t.ParentName], t)
+		} else {
+			t.ParentName = ""
+			roots = append(roots, t)
+		}
+	}
+
+	ordered := make([]domain.Team, 0, len(teams))
+	var walk func(ts []domain.Team)
+	walk = func(ts []domain.Team) {
+		for _, t := range ts {
+			ordered = append(ordered, t)
+			walk(children[t.Name])
+		}
+	}
+	walk(roots)
+
+	return ordered, nil
+}
@@ -2,22 +2,44 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"pr-service/internal/domain"
+	"time"
 )
 
 type TeamService struct {
-	teamRepo UserTeamRepository
+	teamRepo   UserTeamRepository
+	prRepo     PRRepository
+	tx         TxManager
+	reassigner ReviewReassigner
 }
 
-func NewTeamService(tr UserTeamRepository) *TeamService {
+func NewTeamService(tr UserTeamRepository, pr PRRepository, tx TxManager, reassigner ReviewReassigner) *TeamService {
 	return &TeamService{
-		teamRepo: tr,
+		teamRepo:   tr,
+		prRepo:     pr,
+		tx:         tx,
+		reassigner: reassigner,
 	}
 }
 
+// Create creates a new team with its members. Members of another team are
+// rejected with ErrUserInOtherTeam.
 func (s *TeamService) Create(ctx context.Context, team domain.Team) error {
-	return s.teamRepo.CreateTeam(ctx, team)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if team.ParentName != "" {
+			if _, err := s.getWritable(ctx, team.ParentName); err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.otherTeamMembers(ctx, team.Name, team.Members, false); err != nil {
+			return err
+		}
+
+		return s.teamRepo.CreateTeam(ctx, team)
+	})
 }
 
 func (s *TeamService) Get(ctx context.Context, name string) (domain.Team, error) {
@@ -34,6 +56,479 @@ func (s *TeamService) Get(ctx context.Context, name string) (domain.Team, error)
 	return *team, nil
 }
 
+// AddMembers creates the given users in an existing team. Members that
+// already exist are updated; one without a role keeps the current one.
+// Members of another team are rejected with ErrUserInOtherTeam, moving
+// them is up to the user service.
+func (s *TeamService) AddMembers(ctx context.Context, teamName string, members []domain.User) (domain.Team, error) {
+	var team *domain.Team
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.getWritable(ctx, teamName); err != nil {
+			return err
+		}
+
+		if _, err := s.otherTeamMembers(ctx, teamName, members, false); err != nil {
+			return err
+		}
+
+		if err := s.teamRepo.AddMembers(ctx, teamName, members); err != nil {
+			return fmt.Errorf("failed to add members: %w", err)
+		}
+
+		var err error
+		team, err = s.teamRepo.GetByName(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Team{}, err
+	}
+
+	return *team, nil
+}
+
+// RemoveMember takes the user out of the team and deactivates them. With
+// reassignReviews their open reviews are handed over in the same
+// transaction; ErrNoCandidate rolls the removal back.
+func (s *TeamService) RemoveMember(ctx context.Context, teamName, userID string, reassignReviews bool) (*domain.User, []domain.PullRequest, error) {
+	var (
+		user       *domain.User
+		reassigned []domain.PullRequest
+	)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.getWritable(ctx, teamName); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = s.teamRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return domain.ErrNotFound
+		}
+
+		if err := s.teamRepo.RemoveFromTeam(ctx, teamName, userID); err != nil {
+			if errors.Is(err, domain.ErrNotTeamMember) {
+				return err
+			}
+			return fmt.Errorf("failed to remove member: %w", err)
+		}
+		user.TeamName = ""
+		user.IsActive = false
+
+		if !reassignReviews {
+			return nil
+		}
+
+		reassigned, err = reassignOpenReviews(ctx, s.prRepo, s.reassigner, userID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, reassigned, nil
+}
+
+// SetRole changes the member's role in the team. Making someone an observer
+// with reassignReviews hands their open reviews over in the same
+// transaction, since observers are never picked as reviewers.
+func (s *TeamService) SetRole(ctx context.Context, teamName, userID string, role domain.Role, reassignReviews bool) (*domain.User, []domain.PullRequest, error) {
+	if !role.IsValid() {
+		return nil, nil, domain.ErrInvalidRole
+	}
+
+	var (
+		user       *domain.User
+		reassigned []domain.PullRequest
+	)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.getWritable(ctx, teamName); err != nil {
+			return err
+		}
+
+		if err := s.teamRepo.SetRole(ctx, teamName, userID, role); err != nil {
+			if errors.Is(err, domain.ErrNotTeamMember) {
+				return err
+			}
+			return fmt.Errorf("failed to set role: %w", err)
+		}
+
+		var err error
+		user, err = s.teamRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		if role != domain.RoleObserver || !reassignReviews {
+			return nil
+		}
+
+		reassigned, err = reassignOpenReviews(ctx, s.prRepo, s.reassigner, userID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, reassigned, nil
+}
+
+// Sync makes the team's membership match team.Members exactly, creating the
+// team when it does not exist. Members without a role keep their current
+// one, or become regular members. Members of other teams are moved here as
+// in /users/move and members missing from the list are removed as in
+// RemoveMember; with reassignReviews the open reviews of both are handed
+// over. The returned diff lists only users whose membership or data changed.
+func (s *TeamService) Sync(ctx context.Context, team domain.Team, reassignReviews bool) (domain.TeamDiff, []domain.PullRequest, error) {
+	var (
+		diff       domain.TeamDiff
+		reassigned []domain.PullRequest
+	)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.teamRepo.GetByName(ctx, team.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+		if current != nil && current.IsArchived() {
+			return domain.ErrTeamArchived
+		}
+
+		existing := make(map[string]domain.User)
+		if current != nil {
+			for _, m := range current.Members {
+				existing[m.ID] = m
+			}
+		}
+
+		members := make([]domain.User, len(team.Members))
+		var joining []domain.User
+		for i, m := range team.Members {
+			old, ok := existing[m.ID]
+			if m.Role == "" {
+				m.Role = domain.RoleMember
+				if ok {
+					m.Role = old.Role
+				}
+			}
+			if !ok {
+				joining = append(joining, m)
+			}
+			members[i] = m
+		}
+		team.Members = members
+
+		moved, err := s.otherTeamMembers(ctx, team.Name, joining, true)
+		if err != nil {
+			return err
+		}
+
+		// Moved members are reassigned after the upsert, so that they are
+		// no longer candidates in their old team.
+		reassignMoved := func() error {
+			if !reassignReviews {
+				return nil
+			}
+			for _, id := range moved {
+				prs, err := reassignOpenReviews(ctx, s.prRepo, s.reassigner, id)
+				if err != nil {
+					return err
+				}
+				reassigned = mergePRs(reassigned, prs)
+			}
+			return nil
+		}
+
+		if current == nil {
+			if err := s.teamRepo.CreateTeam(ctx, team); err != nil {
+				return fmt.Errorf("failed to create team: %w", err)
+			}
+			diff.Created = true
+			diff.Added = team.Members
+			diff.Team = team
+			return reassignMoved()
+		}
+
+		wanted := make(map[string]bool, len(team.Members))
+		for _, m := range team.Members {
+			wanted[m.ID] = true
+
+			old, ok := existing[m.ID]
+			switch {
+			case !ok:
+				diff.Added = append(diff.Added, m)
+			case old.Username != m.Username || old.IsActive != m.IsActive || old.Role != m.Role:
+				diff.Updated = append(diff.Updated, m)
+			}
+		}
+
+		if err := s.teamRepo.AddMembers(ctx, team.Name, team.Members); err != nil {
+			return fmt.Errorf("failed to upsert members: %w", err)
+		}
+		if err := reassignMoved(); err != nil {
+			return err
+		}
+
+		for _, m := range current.Members {
+			if wanted[m.ID] {
+				continue
+			}
+
+			if err := s.teamRepo.RemoveFromTeam(ctx, team.Name, m.ID); err != nil {
+				return fmt.Errorf("failed to remove member %s: %w", m.ID, err)
+			}
+			m.TeamName = ""
+			m.IsActive = false
+			diff.Removed = append(diff.Removed, m)
+
+			if !reassignReviews {
+				continue
+			}
+
+			prs, err := reassignOpenReviews(ctx, s.prRepo, s.reassigner, m.ID)
+			if err != nil {
+				return err
+			}
+			reassigned = mergePRs(reassigned, prs)
+		}
+
+		synced, err := s.teamRepo.GetByName(ctx, team.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+		diff.Team = *synced
+
+		return nil
+	})
+	if err != nil {
+		return domain.TeamDiff{}, nil, err
+	}
+
+	return diff, reassigned, nil
+}
+
+// List returns a page of teams and the total count. Archived teams are
+// hidden unless includeArchived is set.
+func (s *TeamService) List(ctx context.Context, includeArchived bool, page domain.Page) ([]domain.TeamSummary, int, error) {
+	teams, total, err := s.teamRepo.ListTeams(ctx, includeArchived, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	return teams, total, nil
+}
+
 func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) error {
 	return s.teamRepo.DeactivateByTeam(ctx, teamName)
 }
+
+// Archive retires the team: it becomes read-only and all its members are
+// deactivated. Pull requests and their history stay untouched. Archiving an
+// archived team is a no-op.
+func (s *TeamService) Archive(ctx context.Context, name string) (domain.Team, error) {
+	var team *domain.Team
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.ArchiveTeam(ctx, name, time.Now()); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+			return fmt.Errorf("failed to archive team: %w", err)
+		}
+
+		if err := s.teamRepo.DeactivateByTeam(ctx, name); err != nil {
+			return fmt.Errorf("failed to deactivate members: %w", err)
+		}
+
+		var err error
+		team, err = s.teamRepo.GetByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Team{}, err
+	}
+
+	return *team, nil
+}
+
+// Delete removes the team together with its members and the pull requests
+// they authored. While open PRs involve the team's members it fails with
+// ErrTeamHasOpenPRs unless force is set. It returns the number of open PRs
+// that were involved.
+func (s *TeamService) Delete(ctx context.Context, name string, force bool) (int, error) {
+	var openPRs int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		team, err := s.teamRepo.GetByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+		if team == nil {
+			return domain.ErrNotFound
+		}
+
+		openPRs, err = s.prRepo.CountOpenByTeam(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to count open PRs: %w", err)
+		}
+		if openPRs > 0 && !force {
+			return domain.ErrTeamHasOpenPRs
+		}
+
+		if err := s.teamRepo.DeleteTeam(ctx, name); err != nil {
+			return fmt.Errorf("failed to delete team: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return openPRs, nil
+}
+
+// SetParent moves the team under parent, or makes it a root when parent is
+// empty. The parent must exist and not be archived; a parent inside the
+// team's own subtree is rejected with ErrTeamCycle.
+func (s *TeamService) SetParent(ctx context.Context, name, parent string) (domain.Team, error) {
+	var team *domain.Team
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.getWritable(ctx, name); err != nil {
+			return err
+		}
+
+		if parent != "" {
+			if _, err := s.getWritable(ctx, parent); err != nil {
+				return err
+			}
+		}
+
+		if err := s.teamRepo.SetParent(ctx, name, parent); err != nil {
+			if errors.Is(err, domain.ErrTeamCycle) || errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+			return fmt.Errorf("failed to set parent: %w", err)
+		}
+
+		var err error
+		team, err = s.teamRepo.GetByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Team{}, err
+	}
+
+	return *team, nil
+}
+
+// Tree returns the hierarchy below root, or the whole forest when root is
+// empty, with every node's stats rolled up over its subtree. Archived teams
+// and everything below them are hidden unless includeArchived is set.
+func (s *TeamService) Tree(ctx context.Context, root string, includeArchived bool) ([]*domain.TeamNode, error) {
+	rows, err := s.teamRepo.GetTree(ctx, root, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team tree: %w", err)
+	}
+	if root != "" && len(rows) == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	// Rows come parents first, so every parent is indexed before its
+	// children are attached.
+	nodes := make(map[string]*domain.TeamNode, len(rows))
+	var roots []*domain.TeamNode
+	for i := range rows {
+		node := &rows[i]
+		node.Children = []*domain.TeamNode{}
+		nodes[node.Name] = node
+
+		if parent, ok := nodes[node.ParentName]; ok && node.Name != root {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	for _, node := range roots {
+		rollUp(node)
+	}
+
+	return roots, nil
+}
+
+func rollUp(node *domain.TeamNode) domain.TeamStats {
+	node.Total = node.Own
+	for _, child := range node.Children {
+		node.Total = node.Total.Add(rollUp(child))
+	}
+	return node.Total
+}
+
+// getWritable returns the team unless it is missing or archived.
+func (s *TeamService) getWritable(ctx context.Context, name string) (*domain.Team, error) {
+	team, err := s.teamRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	if team == nil {
+		return nil, domain.ErrNotFound
+	}
+	if team.IsArchived() {
+		return nil, domain.ErrTeamArchived
+	}
+	return team, nil
+}
+
+// otherTeamMembers returns the ids of members that belong to a team other
+// than teamName. Unless allowMove is set the first such member is reported
+// as ErrUserInOtherTeam instead.
+func (s *TeamService) otherTeamMembers(ctx context.Context, teamName string, members []domain.User, allowMove bool) ([]string, error) {
+	var ids []string
+	for _, m := range members {
+		user, err := s.teamRepo.GetUserByID(ctx, m.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil || user.TeamName == "" || user.TeamName == teamName {
+			continue
+		}
+		if !allowMove {
+			return nil, fmt.Errorf("%w: %s is in %s", domain.ErrUserInOtherTeam, m.ID, user.TeamName)
+		}
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// mergePRs appends updates to prs, replacing earlier states of the same PR.
+func mergePRs(prs, updates []domain.PullRequest) []domain.PullRequest {
+	for _, u := range updates {
+		replaced := false
+		for i := range prs {
+			if prs[i].ID == u.ID {
+				prs[i] = u
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			prs = append(prs, u)
+		}
+	}
+	return prs
+}
@@ -7,18 +7,158 @@ import (
 )
 
 type UserService struct {
-	userRepo UserTeamRepository
-	prRepo   PRRepository
+	userRepo   UserTeamRepository
+	prRepo     PRRepository
+	tx         TxManager
+	reassigner ReviewReassigner
 }
 
-func NewUserService(ur UserTeamRepository, pr PRRepository) *UserService {
+func NewUserService(ur UserTeamRepository, pr PRRepository, tx TxManager, reassigner ReviewReassigner) *UserService {
 	return &UserService{
-		userRepo: ur,
-		prRepo:   pr,
+		userRepo:   ur,
+		prRepo:     pr,
+		tx:         tx,
+		reassigner: reassigner,
 	}
 }
 
 func (s *UserService) SetActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	var user *domain.User
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.setActive(ctx, userID, isActive)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeactivateAndReassign deactivates the user and moves every open review
+// assigned to them to another member of the PR author's team. Either all of
+// it happens or nothing does: if some PR has no replacement candidate the
+// user stays active and ErrNoCandidate is returned.
+func (s *UserService) DeactivateAndReassign(ctx context.Context, userID string) (*domain.User, []domain.PullRequest, error) {
+	var (
+		user       *domain.User
+		reassigned []domain.PullRequest
+	)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.setActive(ctx, userID, false)
+		if err != nil {
+			return err
+		}
+
+		reassigned, err = reassignOpenReviews(ctx, s.prRepo, s.reassigner, userID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, reassigned, nil
+}
+
+// Move puts the user into teamName. With reassignReviews every open review
+// of the user is handed to someone from the PR author's team in the same
+// transaction; ErrNoCandidate rolls the move back.
+func (s *UserService) Move(ctx context.Context, userID, teamName string, reassignReviews bool) (*domain.User, []domain.PullRequest, error) {
+	var (
+		user       *domain.User
+		reassigned []domain.PullRequest
+	)
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.getByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		team, err := s.userRepo.GetByName(ctx, teamName)
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+		if team == nil {
+			return domain.ErrNotFound
+		}
+		if team.IsArchived() {
+			return domain.ErrTeamArchived
+		}
+
+		if user.TeamName != teamName {
+			if err := s.userRepo.SetUserTeam(ctx, userID, teamName); err != nil {
+				return fmt.Errorf("failed to move user: %w", err)
+			}
+			user.TeamName = teamName
+		}
+
+		if !reassignReviews {
+			return nil
+		}
+
+		reassigned, err = reassignOpenReviews(ctx, s.prRepo, s.reassigner, userID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, reassigned, nil
+}
+
+func (s *UserService) UpdateUsername(ctx context.Context, userID, username string) (*domain.User, error) {
+	var user *domain.User
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.getByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.UpdateUsername(ctx, userID, username); err != nil {
+			return fmt.Errorf("failed to update username: %w", err)
+		}
+		user.Username = username
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// reassignOpenReviews hands every open review of userID to another member of
+// the PR author's team.
+func reassignOpenReviews(ctx context.Context, prRepo PRRepository, reassigner ReviewReassigner, userID string) ([]domain.PullRequest, error) {
+	prs, err := prRepo.GetByReviewer(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PRs by reviewer: %w", err)
+	}
+
+	reassigned := make([]domain.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if !pr.IsPROpen() {
+			continue
+		}
+
+		updated, err := reassigner.Reassign(ctx, pr.ID, userID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("reassign PR %s: %w", pr.ID, err)
+		}
+		reassigned = append(reassigned, *updated)
+	}
+
+	return reassigned, nil
+}
+
+func (s *UserService) setActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 
 	if err != nil {
@@ -32,6 +172,16 @@ func (s *UserService) SetActive(ctx context.Context, userID string, isActive boo
 		return user, nil
 	}
 
+	if isActive && user.TeamName != "" {
+		team, err := s.userRepo.GetByName(ctx, user.TeamName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team: %w", err)
+		}
+		if team != nil && team.IsArchived() {
+			return nil, domain.ErrTeamArchived
+		}
+	}
+
 	user.IsActive = isActive
 
 	request := domain.ActivateUserRequest{
@@ -46,6 +196,20 @@ func (s *UserService) SetActive(ctx context.Context, userID string, isActive boo
 	return user, nil
 }
 
+func (s *UserService) Get(ctx context.Context, userID string) (*domain.User, error) {
+	return s.getByID(ctx, userID)
+}
+
+// Search returns a page of users matching filter and the total count.
+func (s *UserService) Search(ctx context.Context, filter domain.UserFilter, page domain.Page) ([]domain.User, int, error) {
+	users, total, err := s.userRepo.SearchUsers(ctx, filter, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	return users, total, nil
+}
+
 func (s *UserService) GetByReviewer(ctx context.Context, reviewerID string) ([]domain.PullRequest, error) {
 	_, err := s.getByID(ctx, reviewerID)
 	if err != nil {
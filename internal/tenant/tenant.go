@@ -0,0 +1,34 @@
+// Package tenant carries the organization a request works in through the
+// context. Repositories scope every query by it.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of data created before tenants existed and of calls
+// that do not name one.
+const Default = "default"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// IsValid reports whether id can be used as a tenant id: lowercase letters,
+// digits, '-' and '_', at most 64 characters.
+func IsValid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type tenantKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant stored by WithID, or Default.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
@@ -23,6 +23,9 @@ const (
 	baseURL      = "http://localhost:8080"
 )
 
+// authToken is sent as the bearer token with every request.
+var authToken string
+
 type requestType string
 
 const (
@@ -74,6 +77,10 @@ func main() {
 	_ = godotenv.Load()
 
 	mode := getEnv("LOAD_MODE", "load")
+	authToken = getEnv("AUTH_BOOTSTRAP_TOKEN", "")
+	if authToken == "" {
+		log.Fatal("AUTH_BOOTSTRAP_TOKEN is not set: export the token the server was started with")
+	}
 	host := getEnv("DB_HOST", "localhost")
 	port := getEnv("DB_PORT", "5432")
 	user := getEnv("DB_USER", "postgres")
@@ -217,6 +224,7 @@ func sendGetTeam(ctx context.Context, client *http.Client, n int) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("new request: %w", err)
 	}
+	req.Header.Set("Authorization", "Bearer "+authToken)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -253,6 +261,7 @@ func sendCreateTeam(ctx context.Context, client *http.Client, n int) (int, error
 		return 0, fmt.Errorf("new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -287,6 +296,7 @@ func sendCreatePR(ctx context.Context, client *http.Client, n int) (int, error)
 		return 0, fmt.Errorf("new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pull_request_assignments (
+    id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL CHECK (kind IN ('CREATE', 'REASSIGN')),
+    strategy VARCHAR(50) NOT NULL,
+    seed BIGINT NOT NULL,
+    replaced_reviewer_id VARCHAR(255),
+    trace JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pr_id) REFERENCES pull_requests(pr_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignments_pr ON pull_request_assignments(pr_id);
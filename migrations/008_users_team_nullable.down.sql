@@ -0,0 +1,4 @@
+-- Users removed from their team cannot be kept once a team is required.
+DELETE FROM users WHERE team_name IS NULL;
+
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_teams_parent;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS parent_name;
@@ -0,0 +1,3 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_name VARCHAR(255) REFERENCES teams(name) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams(parent_name);
@@ -0,0 +1,60 @@
+-- Only the default tenant survives: the keys no longer include the tenant,
+-- so rows of other tenants could collide with it.
+DELETE FROM api_tokens WHERE tenant_id <> 'default';
+DELETE FROM idempotency_keys WHERE tenant_id <> 'default';
+DELETE FROM pull_request_assignments WHERE tenant_id <> 'default';
+DELETE FROM pull_request_reviewers WHERE tenant_id <> 'default';
+DELETE FROM pull_requests WHERE tenant_id <> 'default';
+DELETE FROM users WHERE tenant_id <> 'default';
+DELETE FROM teams WHERE tenant_id <> 'default';
+
+ALTER TABLE api_tokens DROP CONSTRAINT IF EXISTS api_tokens_user_id_fkey;
+ALTER TABLE pull_request_assignments DROP CONSTRAINT IF EXISTS pull_request_assignments_pr_id_fkey;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_pr_id_fkey;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_reviewer_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_parent_name_fkey;
+
+DROP INDEX IF EXISTS idx_users_team;
+DROP INDEX IF EXISTS idx_pr_author;
+DROP INDEX IF EXISTS idx_reviewers_user;
+DROP INDEX IF EXISTS idx_assignments_pr;
+DROP INDEX IF EXISTS idx_teams_parent;
+DROP INDEX IF EXISTS idx_api_tokens_tenant;
+
+ALTER TABLE teams DROP CONSTRAINT teams_pkey, ADD PRIMARY KEY (name);
+ALTER TABLE users DROP CONSTRAINT users_pkey, ADD PRIMARY KEY (user_id);
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey, ADD PRIMARY KEY (pr_id);
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT pull_request_reviewers_pkey, ADD PRIMARY KEY (pr_id, reviewer_id);
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey, ADD PRIMARY KEY (idempotency_key);
+
+ALTER TABLE teams DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+ALTER TABLE pull_requests DROP COLUMN tenant_id;
+ALTER TABLE pull_request_reviewers DROP COLUMN tenant_id;
+ALTER TABLE pull_request_assignments DROP COLUMN tenant_id;
+ALTER TABLE idempotency_keys DROP COLUMN tenant_id;
+ALTER TABLE api_tokens DROP COLUMN tenant_id;
+
+ALTER TABLE teams ADD CONSTRAINT teams_parent_name_fkey
+    FOREIGN KEY (parent_name) REFERENCES teams(name) ON DELETE SET NULL;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(name) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_pr_id_fkey
+    FOREIGN KEY (pr_id) REFERENCES pull_requests(pr_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+    FOREIGN KEY (reviewer_id) REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_assignments ADD CONSTRAINT pull_request_assignments_pr_id_fkey
+    FOREIGN KEY (pr_id) REFERENCES pull_requests(pr_id) ON DELETE CASCADE;
+ALTER TABLE api_tokens ADD CONSTRAINT api_tokens_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_users_team ON users(team_name);
+CREATE INDEX IF NOT EXISTS idx_pr_author ON pull_requests(author_id);
+CREATE INDEX IF NOT EXISTS idx_reviewers_pr ON pull_request_reviewers(pr_id);
+CREATE INDEX IF NOT EXISTS idx_reviewers_user ON pull_request_reviewers(reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_assignments_pr ON pull_request_assignments(pr_id);
+CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams(parent_name);
@@ -0,0 +1,59 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_request_reviewers ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_request_assignments ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Existing rows now belong to the default tenant; new rows must name one.
+ALTER TABLE teams ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE pull_requests ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE pull_request_reviewers ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE pull_request_assignments ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE idempotency_keys ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_tokens ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE api_tokens DROP CONSTRAINT IF EXISTS api_tokens_user_id_fkey;
+ALTER TABLE pull_request_assignments DROP CONSTRAINT IF EXISTS pull_request_assignments_pr_id_fkey;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_pr_id_fkey;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT IF EXISTS pull_request_reviewers_reviewer_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_author_id_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_parent_name_fkey;
+
+ALTER TABLE teams DROP CONSTRAINT teams_pkey, ADD PRIMARY KEY (tenant_id, name);
+ALTER TABLE users DROP CONSTRAINT users_pkey, ADD PRIMARY KEY (tenant_id, user_id);
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey, ADD PRIMARY KEY (tenant_id, pr_id);
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT pull_request_reviewers_pkey, ADD PRIMARY KEY (tenant_id, pr_id, reviewer_id);
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey, ADD PRIMARY KEY (tenant_id, idempotency_key);
+
+ALTER TABLE teams ADD CONSTRAINT teams_parent_name_fkey
+    FOREIGN KEY (tenant_id, parent_name) REFERENCES teams(tenant_id, name) ON DELETE SET NULL (parent_name);
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, name) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (tenant_id, author_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_pr_id_fkey
+    FOREIGN KEY (tenant_id, pr_id) REFERENCES pull_requests(tenant_id, pr_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_reviewers ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+    FOREIGN KEY (tenant_id, reviewer_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE;
+ALTER TABLE pull_request_assignments ADD CONSTRAINT pull_request_assignments_pr_id_fkey
+    FOREIGN KEY (tenant_id, pr_id) REFERENCES pull_requests(tenant_id, pr_id) ON DELETE CASCADE;
+ALTER TABLE api_tokens ADD CONSTRAINT api_tokens_user_id_fkey
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_users_team;
+DROP INDEX IF EXISTS idx_pr_author;
+DROP INDEX IF EXISTS idx_reviewers_pr;
+DROP INDEX IF EXISTS idx_reviewers_user;
+DROP INDEX IF EXISTS idx_assignments_pr;
+DROP INDEX IF EXISTS idx_teams_parent;
+
+CREATE INDEX IF NOT EXISTS idx_users_team ON users(tenant_id, team_name);
+CREATE INDEX IF NOT EXISTS idx_pr_author ON pull_requests(tenant_id, author_id);
+CREATE INDEX IF NOT EXISTS idx_reviewers_user ON pull_request_reviewers(tenant_id, reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_assignments_pr ON pull_request_assignments(tenant_id, pr_id);
+CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams(tenant_id, parent_name);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_tenant ON api_tokens(tenant_id);
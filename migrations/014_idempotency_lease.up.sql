@@ -0,0 +1,5 @@
+-- A reservation without a response is only held until locked_until; after
+-- that a retry takes the key over. Reservations left by earlier versions are
+-- released right away.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE idempotency_keys ALTER COLUMN locked_until DROP DEFAULT;
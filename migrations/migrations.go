@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL migrations into the binary, so the
+// service can migrate its database without the files on disk.
+package migrations
+
+import "embed"
+
+// FS holds the "<version>_<name>.up.sql" and "<version>_<name>.down.sql"
+// files.
+//
+//go:embed *.sql
+var FS embed.FS
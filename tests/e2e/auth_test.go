@@ -0,0 +1,28 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"pr-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingToken_E2E(t *testing.T) {
+
+	// A bare client, so that the default admin token is not added.
+	resp, err := (&http.Client{}).Get(host + "/team/list")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var errResp domain.ErrorResponse
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ErrCodeUnauthorized, errResp.Error.Code)
+}
@@ -2,4 +2,6 @@ package e2e
 
 const (
 	host = "http://localhost:8080"
+	// metricsHost serves /metrics apart from the API.
+	metricsHost = "http://localhost:9464"
 )
@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"pr-service/internal/handlers/dto"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain needs the bootstrap admin token the server was started with in
+// AUTH_BOOTSTRAP_TOKEN. Requests sent through http.DefaultClient authenticate
+// with it unless they set Authorization themselves.
+func TestMain(m *testing.M) {
+	_ = godotenv.Load("../../.env")
+
+	token := os.Getenv("AUTH_BOOTSTRAP_TOKEN")
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "AUTH_BOOTSTRAP_TOKEN is not set: export the token the server was started with")
+		os.Exit(1)
+	}
+	http.DefaultClient.Transport = bearerTransport{token: token}
+
+	os.Exit(m.Run())
+}
+
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// ensureTeam makes the team match teamReq whether or not an earlier run
+// already created it.
+func ensureTeam(t *testing.T, serverURL string, teamReq dto.CreateTeamIn) {
+	t.Helper()
+
+	body, err := json.Marshal(dto.SyncTeamIn{Name: teamReq.Name, Members: teamReq.Members})
+	require.NoError(t, err)
+
+	resp, err := http.Post(serverURL+"/team/sync", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Contains(t, []int{http.StatusOK, http.StatusCreated}, resp.StatusCode)
+}
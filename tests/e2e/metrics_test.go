@@ -0,0 +1,24 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_E2E(t *testing.T) {
+	// A bare client: the scraper sends no token.
+	resp, err := (&http.Client{}).Get(metricsHost + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "# TYPE pr_service_http_requests_total counter")
+	assert.Contains(t, string(body), "# TYPE pr_service_db_open_connections gauge")
+}
@@ -26,13 +26,7 @@ func createTeamForPR(t *testing.T, serverURL string) (authorID, reviewerID strin
 			{ID: "u2", Username: "Bob", IsActive: true},
 		},
 	}
-	body, err := json.Marshal(teamReq)
-	require.NoError(t, err)
-
-	resp, err := http.Post(serverURL+"/team/add", "application/json", bytes.NewBuffer(body))
-	require.NoError(t, err)
-	defer resp.Body.Close()
-	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	ensureTeam(t, serverURL, teamReq)
 
 	return "u1", "u2"
 }
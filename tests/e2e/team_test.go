@@ -17,8 +17,9 @@ import (
 )
 
 func TestCreateTeam_E2E(t *testing.T) {
+	teamName := "Backend Team " + strconv.Itoa(rand.Int())
 	teamReq := dto.CreateTeamIn{
-		Name: "Backend Team",
+		Name: teamName,
 		Members: []dto.UserDTO{
 			{ID: "user1", Username: "Alice", IsActive: true},
 			{ID: "user2", Username: "Bob", IsActive: true},
@@ -42,7 +43,7 @@ func TestCreateTeam_E2E(t *testing.T) {
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	require.NoError(t, err)
 
-	assert.Equal(t, "Backend Team", result.Team.Name)
+	assert.Equal(t, teamName, result.Team.Name)
 	assert.Len(t, result.Team.Members, 2)
 	assert.Equal(t, "Alice", result.Team.Members[0].Username)
 	assert.Equal(t, "Bob", result.Team.Members[1].Username)
@@ -50,8 +51,9 @@ func TestCreateTeam_E2E(t *testing.T) {
 
 func TestCreateTeam_AddNewMembers_E2E(t *testing.T) {
 	user := "user" + strconv.Itoa(rand.Int())
+	teamName := "Backend Team " + strconv.Itoa(rand.Int())
 	teamReq := dto.CreateTeamIn{
-		Name: "Backend Team",
+		Name: teamName,
 		Members: []dto.UserDTO{
 			{ID: user, Username: "Alice", IsActive: true},
 		},
@@ -67,22 +69,25 @@ func TestCreateTeam_AddNewMembers_E2E(t *testing.T) {
 
 	user2 := "user" + strconv.Itoa(rand.Int())
 
-	teamReq.Members = []dto.UserDTO{
-		{ID: user2, Username: "Bob", IsActive: true},
+	addReq := dto.AddTeamMembersIn{
+		TeamName: teamName,
+		Members: []dto.UserDTO{
+			{ID: user2, Username: "Bob", IsActive: true},
+		},
 	}
-	body, err = json.Marshal(teamReq)
+	body, err = json.Marshal(addReq)
 	require.NoError(t, err)
 
-	resp2, err := http.Post(host+"/team/add", "application/json", bytes.NewBuffer(body))
+	resp2, err := http.Post(host+"/team/addMembers", "application/json", bytes.NewBuffer(body))
 	require.NoError(t, err)
 	defer resp2.Body.Close()
 
-	assert.Equal(t, http.StatusCreated, resp2.StatusCode)
+	assert.Equal(t, http.StatusOK, resp2.StatusCode)
 
 	u, err := url.Parse(host + "/team/get")
 	require.NoError(t, err)
 	q := u.Query()
-	q.Set("team_name", "Backend Team")
+	q.Set("team_name", teamName)
 	u.RawQuery = q.Encode()
 
 	resp3, err := http.Get(u.String())
@@ -95,7 +100,7 @@ func TestCreateTeam_AddNewMembers_E2E(t *testing.T) {
 	err = json.NewDecoder(resp3.Body).Decode(&result)
 	require.NoError(t, err)
 
-	assert.Equal(t, "Backend Team", result.Name)
+	assert.Equal(t, teamName, result.Name)
 
 	expectedUserIDs := map[string]bool{
 		user:  false,
@@ -118,9 +123,9 @@ func TestCreateTeam_AddNewMembers_E2E(t *testing.T) {
 }
 
 func TestGetTeam_E2E(t *testing.T) {
-
+	teamName := "QA Team " + strconv.Itoa(rand.Int())
 	teamReq := dto.CreateTeamIn{
-		Name: "QA Team",
+		Name: teamName,
 		Members: []dto.UserDTO{
 			{ID: "user3", Username: "Charlie", IsActive: true},
 		},
@@ -137,7 +142,7 @@ func TestGetTeam_E2E(t *testing.T) {
 	u, err := url.Parse(host + "/team/get")
 	require.NoError(t, err)
 	q := u.Query()
-	q.Set("team_name", "QA Team")
+	q.Set("team_name", teamName)
 	u.RawQuery = q.Encode()
 
 	resp2, err := http.Get(u.String())
@@ -150,11 +155,82 @@ func TestGetTeam_E2E(t *testing.T) {
 	err = json.NewDecoder(resp2.Body).Decode(&result)
 	require.NoError(t, err)
 
-	assert.Equal(t, "QA Team", result.Name)
+	assert.Equal(t, teamName, result.Name)
 	assert.Len(t, result.Members, 1)
 	assert.Equal(t, "Charlie", result.Members[0].Username)
 }
 
+func TestCreateTeam_AlreadyExists_E2E(t *testing.T) {
+	teamReq := dto.CreateTeamIn{
+		Name: "Duplicate Team " + strconv.Itoa(rand.Int()),
+		Members: []dto.UserDTO{
+			{ID: "user" + strconv.Itoa(rand.Int()), Username: "Dave", IsActive: true},
+		},
+	}
+	body, err := json.Marshal(teamReq)
+	require.NoError(t, err)
+
+	resp, err := http.Post(host+"/team/add", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp2, err := http.Post(host+"/team/add", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp2.StatusCode)
+
+	var errResp domain.ErrorResponse
+	err = json.NewDecoder(resp2.Body).Decode(&errResp)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ErrCodeTeamExists, errResp.Error.Code)
+}
+
+func TestSyncTeam_E2E(t *testing.T) {
+	teamName := "Sync Team " + strconv.Itoa(rand.Int())
+	kept := "user" + strconv.Itoa(rand.Int())
+	dropped := "user" + strconv.Itoa(rand.Int())
+	added := "user" + strconv.Itoa(rand.Int())
+
+	sync := func(members []dto.UserDTO) (int, dto.SyncTeamOut) {
+		body, err := json.Marshal(dto.SyncTeamIn{Name: teamName, Members: members})
+		require.NoError(t, err)
+
+		resp, err := http.Post(host+"/team/sync", "application/json", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var out dto.SyncTeamOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out
+	}
+
+	status, out := sync([]dto.UserDTO{
+		{ID: kept, Username: "Erin", IsActive: true},
+		{ID: dropped, Username: "Frank", IsActive: true},
+	})
+	assert.Equal(t, http.StatusCreated, status)
+	assert.True(t, out.Created)
+	assert.Len(t, out.Added, 2)
+
+	status, out = sync([]dto.UserDTO{
+		{ID: kept, Username: "Erin Smith", IsActive: true},
+		{ID: added, Username: "Grace", IsActive: true},
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.False(t, out.Created)
+	require.Len(t, out.Added, 1)
+	assert.Equal(t, added, out.Added[0].ID)
+	require.Len(t, out.Updated, 1)
+	assert.Equal(t, kept, out.Updated[0].ID)
+	require.Len(t, out.Removed, 1)
+	assert.Equal(t, dropped, out.Removed[0].ID)
+	assert.False(t, out.Removed[0].IsActive)
+	assert.Len(t, out.Team.Members, 2)
+}
+
 func TestGetTeam_NotFound_E2E(t *testing.T) {
 	u, err := url.Parse(host + "/team/get")
 	require.NoError(t, err)
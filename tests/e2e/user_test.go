@@ -22,19 +22,13 @@ func TestSetUserActive_E2E(t *testing.T) {
 			{ID: "u1", Username: "Alice", IsActive: true},
 		},
 	}
-	body, err := json.Marshal(teamReq)
-	require.NoError(t, err)
-
-	resp, err := http.Post(host+"/team/add", "application/json", bytes.NewBuffer(body))
-	require.NoError(t, err)
-	resp.Body.Close()
-	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	ensureTeam(t, host, teamReq)
 
 	setReq := dto.SetUserActiveIn{
 		UserID:   "u1",
 		IsActive: false,
 	}
-	body, err = json.Marshal(setReq)
+	body, err := json.Marshal(setReq)
 	require.NoError(t, err)
 
 	resp2, err := http.Post(host+"/users/setIsActive", "application/json", bytes.NewBuffer(body))
@@ -97,13 +91,7 @@ func TestGetUserReviews_E2E(t *testing.T) {
 			{ID: "u2", Username: "Bob", IsActive: true},
 		},
 	}
-	body, err := json.Marshal(teamReq)
-	require.NoError(t, err)
-
-	resp, err := http.Post(host+"/team/add", "application/json", bytes.NewBuffer(body))
-	require.NoError(t, err)
-	resp.Body.Close()
-	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	ensureTeam(t, host, teamReq)
 
 	u, err := url.Parse(host + "/users/getReview")
 	require.NoError(t, err)
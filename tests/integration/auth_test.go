@@ -0,0 +1,192 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+	appmw "pr-service/internal/handlers/middleware"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	bearer := func(token string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+	get := func(t *testing.T, path, token string) int {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		// A bare client, so that no default token is added.
+		resp, err := (&http.Client{}).Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	errorCode := func(t *testing.T, resp *http.Response) string {
+		defer resp.Body.Close()
+		var errResp domain.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		return errResp.Error.Code
+	}
+
+	resp, err := postJSON(srv.URL+"/team/add", dto.CreateTeamIn{
+		Name: "auth-team",
+		Members: []dto.UserDTO{
+			{ID: "a1", Username: "author", IsActive: true},
+			{ID: "a2", Username: "reviewer1", IsActive: true},
+			{ID: "a3", Username: "reviewer2", IsActive: true},
+			{ID: "a4", Username: "spare", IsActive: true},
+		},
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("missing or unknown token is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get(t, "/team/get?team_name=auth-team", ""))
+		assert.Equal(t, http.StatusUnauthorized, get(t, "/team/get?team_name=auth-team", "prs_unknown"))
+		assert.Equal(t, http.StatusOK, get(t, "/team/get?team_name=auth-team", testAdminToken))
+	})
+
+	issue := func(t *testing.T, req dto.IssueTokenIn) dto.IssueTokenOut {
+		resp, err := postJSON(srv.URL+"/auth/issueToken", req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var out dto.IssueTokenOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out
+	}
+
+	userToken := issue(t, dto.IssueTokenIn{Name: "a2 laptop", Scope: "user", UserID: "a2"})
+
+	t.Run("only the hash is stored", func(t *testing.T) {
+		var hash string
+		require.NoError(t, db.Get(&hash, `SELECT token_hash FROM api_tokens WHERE token_id = $1`, userToken.Token.ID))
+		assert.Equal(t, auth.HashToken(userToken.Secret), hash)
+		assert.NotContains(t, hash, userToken.Secret)
+	})
+
+	t.Run("issued secrets are not stored for replay", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/auth/issueToken",
+			dto.IssueTokenIn{Name: "a3 laptop", Scope: "user", UserID: "a3"},
+			map[string]string{appmw.IdempotencyKeyHeader: "issue-a3"})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var out dto.IssueTokenOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		require.NotEmpty(t, out.Secret)
+
+		var stored int
+		require.NoError(t, db.Get(&stored, `
+			SELECT COUNT(*) FROM idempotency_keys
+			WHERE idempotency_key = 'issue-a3' OR position($1::bytea IN response_body) > 0`, []byte(out.Secret)))
+		assert.Zero(t, stored, "no idempotency record may hold the secret")
+	})
+
+	t.Run("user token needs a user", func(t *testing.T) {
+		resp, err := postJSON(srv.URL+"/auth/issueToken", dto.IssueTokenIn{Name: "nobody", Scope: "user"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeInvalidData, errorCode(t, resp))
+	})
+
+	var pr dto.PullRequestWrapper
+
+	t.Run("user scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(t, "/team/get?team_name=auth-team", userToken.Secret))
+
+		resp, err := postJSONWithHeaders(srv.URL+"/team/add", dto.CreateTeamIn{
+			Name:    "forbidden-team",
+			Members: []dto.UserDTO{{ID: "a9", Username: "x", IsActive: true}},
+		}, bearer(userToken.Secret))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeForbidden, errorCode(t, resp))
+
+		resp, err = postJSONWithHeaders(srv.URL+"/auth/issueToken",
+			dto.IssueTokenIn{Name: "escalate", Scope: "admin"}, bearer(userToken.Secret))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp.Body.Close()
+
+		resp, err = postJSONWithHeaders(srv.URL+"/pullRequest/create",
+			dto.CreatePullRequestIn{ID: "pr-auth", Name: "Auth", AuthorID: "a1"}, bearer(userToken.Secret))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeForbidden, errorCode(t, resp))
+
+		resp, err = postJSON(srv.URL+"/pullRequest/create", dto.CreatePullRequestIn{ID: "pr-auth", Name: "Auth", AuthorID: "a1"})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&pr))
+		resp.Body.Close()
+	})
+
+	t.Run("user may reassign only their own review", func(t *testing.T) {
+		var other string
+		for _, id := range pr.PR.Reviewers {
+			if id != "a2" {
+				other = id
+			}
+		}
+		require.NotEmpty(t, other)
+
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/reassign",
+			dto.ReassignReviewerRequest{PullRequestID: "pr-auth", OldReviewerID: other}, bearer(userToken.Secret))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp.Body.Close()
+
+		if !contains(pr.PR.Reviewers, "a2") {
+			return
+		}
+		resp, err = postJSONWithHeaders(srv.URL+"/pullRequest/reassign",
+			dto.ReassignReviewerRequest{PullRequestID: "pr-auth", OldReviewerID: "a2"}, bearer(userToken.Secret))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	})
+
+	t.Run("revoked token is rejected", func(t *testing.T) {
+		resp, err := postJSON(srv.URL+"/auth/revokeToken", dto.RevokeTokenIn{TokenID: userToken.Token.ID})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var out dto.TokenWrapper
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		assert.NotNil(t, out.Token.RevokedAt)
+
+		assert.Equal(t, http.StatusUnauthorized, get(t, "/team/get?team_name=auth-team", userToken.Secret))
+	})
+}
@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"pr-service/internal/auth"
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	as := func(userID string) context.Context {
+		return auth.WithIdentity(context.Background(), auth.Identity{TokenID: "t-" + userID, Scope: domain.ScopeUser, UserID: userID})
+	}
+
+	// z2 and z3 end up as reviewers, z4 is the lead, z5 and z6 are spare
+	// members, z7 is an outsider from another team.
+	setup := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "authz", Members: []domain.User{
+			{ID: "z1", Username: "author", IsActive: true},
+			{ID: "z2", Username: "rev1", IsActive: true},
+			{ID: "z3", Username: "rev2", IsActive: true},
+		}}))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "elsewhere", Members: []domain.User{
+			{ID: "z7", Username: "outsider", IsActive: true},
+		}}))
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "zpr", Name: "authz", AuthorID: "z1"})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"z2", "z3"}, created.AssignedReviewers)
+
+		_, err = teamService.AddMembers(ctx, "authz", []domain.User{
+			{ID: "z4", Username: "lead", IsActive: true, Role: domain.RoleLead},
+			{ID: "z5", Username: "spare1", IsActive: true},
+			{ID: "z6", Username: "spare2", IsActive: true},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("reassign", func(t *testing.T) {
+		setup(t)
+
+		_, err := prService.Reassign(as("z7"), "zpr", "z2", 0)
+		require.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = prService.Reassign(as("z5"), "zpr", "z2", 0)
+		require.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = prService.Reassign(as("z3"), "zpr", "z2", 0)
+		require.ErrorIs(t, err, domain.ErrForbidden, "a reviewer cannot hand over someone else's review")
+
+		_, err = prService.Reassign(context.Background(), "zpr", "z2", 0)
+		require.ErrorIs(t, err, domain.ErrForbidden)
+
+		for _, who := range []string{"z2", "z1", "z4"} {
+			current, err := prService.Get(ctx, "zpr")
+			require.NoError(t, err)
+
+			old := current.AssignedReviewers[0]
+			if who == "z2" {
+				old = "z2"
+			}
+
+			updated, err := prService.Reassign(as(who), "zpr", old, 0)
+			require.NoError(t, err, who)
+			assert.NotContains(t, updated.AssignedReviewers, old)
+		}
+	})
+
+	t.Run("create", func(t *testing.T) {
+		setup(t)
+
+		_, err := prService.Create(as("z7"), domain.PullRequestCreate{ID: "zpr2", Name: "spoofed", AuthorID: "z1"})
+		require.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = prService.Create(context.Background(), domain.PullRequestCreate{ID: "zpr2", Name: "anonymous", AuthorID: "z1"})
+		require.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = prService.Get(ctx, "zpr2")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		created, err := prService.Create(as("z1"), domain.PullRequestCreate{ID: "zpr2", Name: "own", AuthorID: "z1"})
+		require.NoError(t, err)
+		assert.Equal(t, "z1", created.AuthorID)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		setup(t)
+
+		for _, who := range []string{"z2", "z4", "z7"} {
+			_, err := prService.Merge(as(who), "zpr", 0)
+			require.ErrorIs(t, err, domain.ErrForbidden, who)
+		}
+
+		merged, err := prService.Merge(as("z1"), "zpr", 0)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PRStatusMerged, merged.Status)
+	})
+
+	t.Run("admin may do everything", func(t *testing.T) {
+		setup(t)
+
+		current, err := prService.Get(ctx, "zpr")
+		require.NoError(t, err)
+
+		_, err = prService.Reassign(ctx, "zpr", current.AssignedReviewers[0], 0)
+		require.NoError(t, err)
+
+		_, err = prService.Merge(ctx, "zpr", 0)
+		require.NoError(t, err)
+	})
+}
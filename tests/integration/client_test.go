@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pr-service/internal/client"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/handlers/mapper"
+	"pr-service/internal/orgchart"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	var last *http.Request
+	var lastBody map[string]any
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /team/add", func(w http.ResponseWriter, r *http.Request) {
+		var in dto.CreateTeamIn
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		handlers.RespondJSON(w, http.StatusCreated, dto.TeamWrapper{Team: dto.CreateTeamOut{Name: in.Name, Members: in.Members}})
+	})
+	mux.HandleFunc("GET /team/list", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RespondJSON(w, http.StatusOK, dto.TeamListOut{
+			Teams:      []dto.TeamSummaryOut{{Name: "backend", MemberCount: 2}},
+			Pagination: dto.PaginationOut{Limit: 10, Offset: 5, Total: 6},
+		})
+	})
+	mux.HandleFunc("POST /pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastBody))
+		handlers.RespondError(w, http.StatusPreconditionFailed, domain.ErrCodeVersionMismatch, domain.ErrVersionMismatch.Error())
+	})
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RespondJSON(w, http.StatusOK, dto.StatsOut{
+			Total:        1,
+			PullRequests: []dto.CreatePullRequestOut{{ID: "pr-1", Status: domain.PRStatusOpen, Reviewers: []string{"u2"}}},
+		})
+	})
+	mux.HandleFunc("POST /team/import", func(w http.ResponseWriter, r *http.Request) {
+		handlers.RespondJSON(w, http.StatusBadRequest, mapper.ImportIssuesToResponse(domain.ImportIssues{
+			{Line: 3, Team: "backend", UserID: "u1", Message: "user already listed on line 2"},
+		}))
+	})
+	mux.HandleFunc("GET /team/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", orgchart.ContentType(r.URL.Query().Get("format")))
+		_, _ = w.Write([]byte("teams: []\n"))
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := client.New(srv.URL+"/", "secret", client.WithTenant("acme"))
+
+	t.Run("sends token and tenant", func(t *testing.T) {
+		team, err := c.CreateTeam(ctx, dto.CreateTeamIn{
+			Name:    "backend",
+			Members: []dto.UserDTO{{ID: "u1", Username: "Alice", IsActive: true}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "backend", team.Name)
+		require.Len(t, team.Members, 1)
+
+		assert.Equal(t, "Bearer secret", last.Header.Get("Authorization"))
+		assert.Equal(t, "acme", last.Header.Get(client.TenantHeader))
+		assert.Equal(t, "application/json", last.Header.Get("Content-Type"))
+	})
+
+	t.Run("query parameters", func(t *testing.T) {
+		list, err := c.ListTeams(ctx, true, 10, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 6, list.Pagination.Total)
+
+		assert.Equal(t, "true", last.URL.Query().Get("include_archived"))
+		assert.Equal(t, "10", last.URL.Query().Get("limit"))
+		assert.Equal(t, "5", last.URL.Query().Get("offset"))
+
+		_, err = c.ListTeams(ctx, false, 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, last.URL.RawQuery)
+	})
+
+	t.Run("API errors", func(t *testing.T) {
+		_, err := c.MergePR(ctx, "pr-1", 3)
+
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr), err)
+		assert.Equal(t, http.StatusPreconditionFailed, apiErr.Status)
+		assert.Equal(t, domain.ErrCodeVersionMismatch, apiErr.Code)
+		assert.Equal(t, `"3"`, last.Header.Get("If-Match"))
+		assert.Equal(t, "pr-1", lastBody["pull_request_id"])
+	})
+
+	t.Run("non-JSON errors", func(t *testing.T) {
+		_, err := c.GetTeam(ctx, "backend")
+
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr), err)
+		assert.Equal(t, http.StatusNotFound, apiErr.Status)
+		assert.Empty(t, apiErr.Code)
+	})
+
+	t.Run("import issues", func(t *testing.T) {
+		_, err := c.ImportTeams(ctx, orgchart.CSV, strings.NewReader("team_name\nbackend\n"), true, false)
+
+		var apiErr *client.APIError
+		require.True(t, errors.As(err, &apiErr), err)
+		assert.Equal(t, domain.ErrCodeInvalidImport, apiErr.Code)
+		require.Len(t, apiErr.Issues, 1)
+		assert.Equal(t, 3, apiErr.Issues[0].Line)
+		assert.Equal(t, "u1", apiErr.Issues[0].UserID)
+
+		assert.Equal(t, "true", last.URL.Query().Get("dry_run"))
+		assert.Equal(t, orgchart.ContentType(orgchart.CSV), last.Header.Get("Content-Type"))
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buf strings.Builder
+		require.NoError(t, c.ExportTeams(ctx, orgchart.YAML, &buf))
+		assert.Equal(t, "teams: []\n", buf.String())
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := c.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Total)
+		require.Len(t, stats.PullRequests, 1)
+		assert.Equal(t, []string{"u2"}, stats.PullRequests[0].Reviewers)
+	})
+}
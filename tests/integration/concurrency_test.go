@@ -0,0 +1,254 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mergeCounter counts merges reported to PRService metrics.
+type mergeCounter struct {
+	merged atomic.Int32
+}
+
+func (c *mergeCounter) PRCreated(context.Context)          {}
+func (c *mergeCounter) PRMerged(context.Context)           { c.merged.Add(1) }
+func (c *mergeCounter) ReviewerReassigned(context.Context) {}
+func (c *mergeCounter) NoCandidate(context.Context)        {}
+
+type callResult struct {
+	status int
+	code   string
+}
+
+// hammer fires n requests at once and collects status and error codes.
+func hammer(t *testing.T, n int, call func(i int) (*http.Response, error)) []callResult {
+	t.Helper()
+
+	results := make([]callResult, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			resp, err := call(i)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			results[i].status = resp.StatusCode
+			if resp.StatusCode >= http.StatusBadRequest {
+				var errResp domain.ErrorResponse
+				if json.NewDecoder(resp.Body).Decode(&errResp) == nil {
+					results[i].code = errResp.Error.Code
+				}
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return results
+}
+
+func assertReviewersConsistent(t *testing.T, repo *pr.PRRepository, prID, authorID string, want int) *domain.PullRequest {
+	t.Helper()
+
+	got, err := repo.GetByID(context.Background(), prID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	assert.Len(t, got.AssignedReviewers, want)
+	seen := make(map[string]bool)
+	for _, id := range got.AssignedReviewers {
+		assert.NotEqual(t, authorID, id)
+		assert.False(t, seen[id], "reviewer %s assigned twice", id)
+		seen[id] = true
+	}
+
+	return got
+}
+
+func TestConcurrencyIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	prRepo := pr.NewPRRepository(db)
+
+	members := []dto.UserDTO{{ID: "c0", Username: "author", IsActive: true}}
+	for i := 1; i <= 7; i++ {
+		members = append(members, dto.UserDTO{ID: fmt.Sprintf("c%d", i), Username: fmt.Sprintf("dev%d", i), IsActive: true})
+	}
+
+	resp, err := postJSON(srv.URL+"/team/add", dto.CreateTeamIn{Name: "race-team", Members: members})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	createPR := func(id string) {
+		resp, err := postJSON(srv.URL+"/pullRequest/create", dto.CreatePullRequestIn{ID: id, Name: id, AuthorID: "c0"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	t.Run("parallel create with the same id", func(t *testing.T) {
+		const workers = 20
+
+		results := hammer(t, workers, func(int) (*http.Response, error) {
+			return postJSON(srv.URL+"/pullRequest/create", dto.CreatePullRequestIn{ID: "pr-race", Name: "Race", AuthorID: "c0"})
+		})
+
+		created := 0
+		for _, res := range results {
+			switch res.status {
+			case http.StatusCreated:
+				created++
+			case http.StatusConflict:
+				assert.Equal(t, domain.ErrCodePRExists, res.code)
+			default:
+				t.Errorf("unexpected status %d (%s)", res.status, res.code)
+			}
+		}
+		assert.Equal(t, 1, created)
+
+		assertReviewersConsistent(t, prRepo, "pr-race", "c0", 2)
+	})
+
+	t.Run("parallel reassign never double-assigns", func(t *testing.T) {
+		createPR("pr-reassign-race")
+
+		const workers = 40
+
+		results := hammer(t, workers, func(i int) (*http.Response, error) {
+			return postJSON(srv.URL+"/pullRequest/reassign", dto.ReassignReviewerRequest{
+				PullRequestID: "pr-reassign-race",
+				OldReviewerID: members[1+i%(len(members)-1)].ID,
+			})
+		})
+
+		for _, res := range results {
+			if res.status == http.StatusOK {
+				continue
+			}
+			assert.Equal(t, http.StatusConflict, res.status)
+			assert.Contains(t, []string{
+				domain.ErrCodeNotAssigned,
+				domain.ErrCodeNoCandidate,
+				domain.ErrCodeReviewerConflict,
+			}, res.code)
+		}
+
+		assertReviewersConsistent(t, prRepo, "pr-reassign-race", "c0", 2)
+	})
+
+	t.Run("merge racing with reassign", func(t *testing.T) {
+		createPR("pr-merge-race")
+
+		const workers = 30
+
+		results := hammer(t, workers, func(i int) (*http.Response, error) {
+			if i%3 == 0 {
+				return postJSON(srv.URL+"/pullRequest/merge", dto.MergePullRequest{ID: "pr-merge-race"})
+			}
+			return postJSON(srv.URL+"/pullRequest/reassign", dto.ReassignReviewerRequest{
+				PullRequestID: "pr-merge-race",
+				OldReviewerID: members[1+i%(len(members)-1)].ID,
+			})
+		})
+
+		for _, res := range results {
+			assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, res.status, res.code)
+		}
+
+		merged := assertReviewersConsistent(t, prRepo, "pr-merge-race", "c0", 2)
+		assert.Equal(t, domain.PRStatusMerged, merged.Status)
+	})
+
+	t.Run("parallel merges are counted once", func(t *testing.T) {
+		createPR("pr-merge-twice")
+		before := assertReviewersConsistent(t, prRepo, "pr-merge-twice", "c0", 2)
+
+		counter := &mergeCounter{}
+		prService := service.NewPRService(prRepo, user_team.NewUserTeamRepository(db), dbtx.NewTxManager(db), service.WithMetrics(counter))
+
+		const workers = 20
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				merged, err := prService.Merge(adminContext(), "pr-merge-twice", 0)
+				if assert.NoError(t, err) {
+					assert.Equal(t, before.Version+1, merged.Version)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), counter.merged.Load())
+		after := assertReviewersConsistent(t, prRepo, "pr-merge-twice", "c0", 2)
+		assert.Equal(t, before.Version+1, after.Version)
+	})
+
+	t.Run("conflict inside an outer transaction keeps the old reviewer", func(t *testing.T) {
+		createPR("pr-nested")
+		before := assertReviewersConsistent(t, prRepo, "pr-nested", "c0", 2)
+		oldReviewer, taken := before.AssignedReviewers[0], before.AssignedReviewers[1]
+
+		ctx := context.Background()
+		err := dbtx.NewTxManager(db).WithinTransaction(ctx, func(ctx context.Context) error {
+			err := prRepo.ReassignReviewer(ctx, "pr-nested", oldReviewer, taken, 0, domain.AssignmentTrace{PRID: "pr-nested"})
+			require.ErrorIs(t, err, domain.ErrReviewerConflict)
+
+			// The outer transaction goes on and commits.
+			got, err := prRepo.GetByID(ctx, "pr-nested")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, before.AssignedReviewers, got.AssignedReviewers)
+			return nil
+		})
+		require.NoError(t, err)
+
+		after := assertReviewersConsistent(t, prRepo, "pr-nested", "c0", 2)
+		assert.ElementsMatch(t, before.AssignedReviewers, after.AssignedReviewers)
+		assert.Equal(t, before.Version, after.Version)
+	})
+
+	require.NoError(t, cleanupDatabase(db))
+}
@@ -0,0 +1,140 @@
+package integration
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pr-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets keys for the test, so that values loaded from .env by
+// other tests do not leak in.
+func clearEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t, "SERVER_PORT", "SERVER_METRICS_PORT", "SERVER_REQUEST_TIMEOUT", "REVIEWER_COUNT", "REVIEWER_STRATEGY")
+
+		cfg, err := config.Load("")
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.Server.Port)
+		assert.Equal(t, 9464, cfg.Server.MetricsPort)
+		assert.Equal(t, 30*time.Second, cfg.Server.RequestTimeout)
+		assert.Equal(t, 2, cfg.Reviewer.Count)
+	})
+
+	t.Run("every invalid value is reported", func(t *testing.T) {
+		t.Setenv("DB_PORT", "five")
+		t.Setenv("SERVER_READ_TIMEOUT", "soon")
+		t.Setenv("REVIEWER_STRATEGY", "best")
+		t.Setenv("TRACING_SAMPLE_RATIO", "2")
+		t.Setenv("RATE_LIMIT_ENABLED", "sometimes")
+		t.Setenv("SERVER_PORT", "8080")
+		t.Setenv("SERVER_METRICS_PORT", "8080")
+		t.Setenv("REVIEWER_HISTORY_WINDOW", "0")
+		t.Setenv("REVIEWER_HISTORY_DECAY", "often")
+		t.Setenv("AUTH_BOOTSTRAP_TOKEN", "dev-admin-token")
+		t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,proxy")
+
+		_, err := config.Load("")
+		require.Error(t, err)
+		for _, want := range []string{
+			`DB_PORT: invalid integer "five"`,
+			`SERVER_READ_TIMEOUT: invalid duration "soon"`,
+			`REVIEWER_STRATEGY: "best" is not one of random, diversity`,
+			`TRACING_SAMPLE_RATIO: must be in [0, 1]`,
+			`RATE_LIMIT_ENABLED: invalid boolean "sometimes"`,
+			`SERVER_METRICS_PORT: must differ from SERVER_PORT`,
+			`REVIEWER_HISTORY_WINDOW: must be positive`,
+			`REVIEWER_HISTORY_DECAY: invalid number "often"`,
+			`AUTH_BOOTSTRAP_TOKEN: is the published example token, set a random one`,
+			`SERVER_TRUSTED_PROXIES: "proxy" is neither an address nor a CIDR range`,
+		} {
+			assert.ErrorContains(t, err, want)
+		}
+	})
+
+	t.Run("file under environment", func(t *testing.T) {
+		clearEnv(t, "SERVER_PORT", "SERVER_WRITE_TIMEOUT", "REVIEWER_COUNT", "AUTH_JWT_ADMIN_GROUPS", "LOG_FORMAT")
+		t.Setenv("SERVER_WRITE_TIMEOUT", "45s")
+
+		path := writeConfigFile(t, `
+server:
+  port: 9090
+  write_timeout: 20s
+reviewer:
+  count: 3
+auth:
+  jwt:
+    admin_groups: [ops, leads]
+LOG_FORMAT: console
+`)
+
+		cfg, err := config.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, 45*time.Second, cfg.Server.WriteTimeout, "environment wins")
+		assert.Equal(t, 3, cfg.Reviewer.Count)
+		assert.Equal(t, []string{"ops", "leads"}, cfg.Auth.JWT.AdminGroups)
+		assert.Equal(t, "console", cfg.Logger.Format)
+
+		sources := make(map[string]string)
+		for _, s := range cfg.Settings() {
+			sources[s.Key] = s.Source
+		}
+		assert.Equal(t, config.SourceFile, sources["SERVER_PORT"])
+		assert.Equal(t, config.SourceEnv, sources["SERVER_WRITE_TIMEOUT"])
+		assert.Equal(t, config.SourceDefault, sources["SERVER_IDLE_TIMEOUT"])
+	})
+
+	t.Run("unknown file keys are rejected", func(t *testing.T) {
+		path := writeConfigFile(t, "server:\n  prot: 9090\n")
+
+		_, err := config.Load(path)
+		assert.ErrorContains(t, err, "unknown setting SERVER_PROT")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := config.Load(filepath.Join(t.TempDir(), "nope.yaml"))
+		assert.Error(t, err)
+	})
+
+	t.Run("printed config redacts secrets and loads back", func(t *testing.T) {
+		clearEnv(t, "SERVER_PORT")
+		t.Setenv("DB_PASSWORD", "hunter2")
+		t.Setenv("AUTH_BOOTSTRAP_TOKEN", "s3cret-token")
+
+		cfg, err := config.Load("")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, cfg.WriteYAML(&buf))
+		out := buf.String()
+
+		assert.NotContains(t, out, "hunter2")
+		assert.NotContains(t, out, "s3cret-token")
+		assert.Contains(t, out, `DB_PASSWORD: "<redacted>" # env`)
+		assert.Contains(t, out, `SERVER_PORT: "8080" # default`)
+
+		reloaded, err := config.Load(writeConfigFile(t, out))
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Server, reloaded.Server)
+		assert.Equal(t, cfg.RateLimit, reloaded.RateLimit)
+	})
+}
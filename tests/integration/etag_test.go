@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	resp, err := postJSON(srv.URL+"/team/add", dto.CreateTeamIn{
+		Name: "etag-team",
+		Members: []dto.UserDTO{
+			{ID: "e1", Username: "author", IsActive: true},
+			{ID: "e2", Username: "reviewer1", IsActive: true},
+			{ID: "e3", Username: "reviewer2", IsActive: true},
+			{ID: "e4", Username: "reviewer3", IsActive: true},
+		},
+	})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = postJSON(srv.URL+"/pullRequest/create", dto.CreatePullRequestIn{ID: "pr-etag", Name: "ETag", AuthorID: "e1"})
+	require.NoError(t, err)
+	var created dto.PullRequestWrapper
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	assert.Equal(t, 1, created.PR.Version)
+
+	errorCode := func(resp *http.Response) string {
+		var errResp domain.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		return errResp.Error.Code
+	}
+
+	t.Run("reassign with current version bumps it", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/reassign", dto.ReassignReviewerRequest{
+			PullRequestID: "pr-etag",
+			OldReviewerID: created.PR.Reviewers[0],
+		}, map[string]string{"If-Match": `"1"`})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("merge with stale version fails", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/merge", dto.MergePullRequest{ID: "pr-etag"},
+			map[string]string{"If-Match": `"1"`})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeVersionMismatch, errorCode(resp))
+	})
+
+	t.Run("get returns current version", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/pullRequest/get?pull_request_id=pr-etag")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("weak tag is rejected", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/merge", dto.MergePullRequest{ID: "pr-etag"},
+			map[string]string{"If-Match": `W/"2"`})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeInvalidData, errorCode(resp))
+	})
+
+	t.Run("merge with current version succeeds", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/merge", dto.MergePullRequest{ID: "pr-etag"},
+			map[string]string{"If-Match": `"2"`})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("malformed If-Match", func(t *testing.T) {
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/merge", dto.MergePullRequest{ID: "pr-etag"},
+			map[string]string{"If-Match": "three"})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, domain.ErrCodeInvalidData, errorCode(resp))
+	})
+
+	require.NoError(t, cleanupDatabase(db))
+}
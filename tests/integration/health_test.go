@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/handlers/dto"
+	healthhand "pr-service/internal/handlers/health_handlers"
+	"pr-service/internal/service"
+	"pr-service/migrations"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	version, err := dbtx.LatestMigration(migrations.FS)
+	require.NoError(t, err)
+	assert.Equal(t, uint(14), version)
+
+	_, err = dbtx.LatestMigration(fstest.MapFS{})
+	assert.Error(t, err)
+}
+
+func TestHealthIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	latest, err := dbtx.LatestMigration(migrations.FS)
+	require.NoError(t, err)
+
+	newServer := func(want uint) (*service.HealthService, *httptest.Server) {
+		healthService := service.NewHealthService(db, dbtx.NewSchema(db), want)
+		r := chi.NewRouter()
+		healthhand.NewHealthHandler(healthService).RegisterRoutes(r)
+		return healthService, httptest.NewServer(r)
+	}
+	readiness := func(t *testing.T, url string) (int, dto.ReadinessOut) {
+		// A bare client: the probes need no token.
+		resp, err := (&http.Client{}).Get(url + "/readyz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var out dto.ReadinessOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out
+	}
+
+	t.Run("liveness and version", func(t *testing.T) {
+		_, srv := newServer(latest)
+		defer srv.Close()
+
+		resp, err := (&http.Client{}).Get(srv.URL + "/healthz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = (&http.Client{}).Get(srv.URL + "/version")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var out dto.VersionOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		assert.NotEmpty(t, out.Version)
+		assert.NotEmpty(t, out.GoVersion)
+	})
+
+	t.Run("ready until drained", func(t *testing.T) {
+		healthService, srv := newServer(latest)
+		defer srv.Close()
+
+		status, out := readiness(t, srv.URL)
+		require.Equal(t, http.StatusOK, status, out)
+		assert.Equal(t, "ready", out.Status)
+
+		healthService.Drain()
+
+		status, out = readiness(t, srv.URL)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "not_ready", out.Status)
+	})
+
+	t.Run("pending migrations", func(t *testing.T) {
+		_, srv := newServer(latest + 1)
+		defer srv.Close()
+
+		status, out := readiness(t, srv.URL)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		for _, c := range out.Checks {
+			if c.Name == "migrations" {
+				assert.False(t, c.OK)
+			}
+		}
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		closed, err := sqlx.Open("postgres", dbDSN)
+		require.NoError(t, err)
+		require.NoError(t, closed.Close())
+
+		healthService := service.NewHealthService(closed, dbtx.NewSchema(closed), latest)
+		r := chi.NewRouter()
+		healthhand.NewHealthHandler(healthService).RegisterRoutes(r)
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+
+		status, out := readiness(t, srv.URL)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "not_ready", out.Status)
+	})
+}
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchyIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager, service.WithSiblingFallback())
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	setup := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "platform", Members: []domain.User{
+			{ID: "h1", Username: "head", IsActive: true},
+		}}))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "infra", ParentName: "platform", Members: []domain.User{
+			{ID: "h2", Username: "solo", IsActive: true},
+		}}))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "storage", ParentName: "platform", Members: []domain.User{
+			{ID: "h3", Username: "disk", IsActive: true},
+			{ID: "h4", Username: "tape", IsActive: false},
+		}}))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "backup", ParentName: "storage", Members: []domain.User{
+			{ID: "h5", Username: "copy", IsActive: true},
+		}}))
+	}
+
+	t.Run("parent must exist", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		err := teamService.Create(ctx, domain.Team{Name: "orphan", ParentName: "missing", Members: []domain.User{
+			{ID: "h9", Username: "lost", IsActive: true},
+		}})
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		setup(t)
+
+		_, err := teamService.SetParent(ctx, "platform", "platform")
+		require.ErrorIs(t, err, domain.ErrTeamCycle)
+
+		_, err = teamService.SetParent(ctx, "platform", "backup")
+		require.ErrorIs(t, err, domain.ErrTeamCycle)
+
+		team, err := teamService.SetParent(ctx, "backup", "infra")
+		require.NoError(t, err)
+		assert.Equal(t, "infra", team.ParentName)
+
+		team, err = teamService.SetParent(ctx, "backup", "")
+		require.NoError(t, err)
+		assert.Empty(t, team.ParentName)
+	})
+
+	t.Run("tree rolls up stats", func(t *testing.T) {
+		setup(t)
+
+		_, err := prService.Create(ctx, domain.PullRequestCreate{ID: "hpr1", Name: "disk quota", AuthorID: "h3"})
+		require.NoError(t, err)
+		_, err = prService.Create(ctx, domain.PullRequestCreate{ID: "hpr2", Name: "restore", AuthorID: "h5"})
+		require.NoError(t, err)
+		_, err = prService.Merge(ctx, "hpr2", 0)
+		require.NoError(t, err)
+
+		roots, err := teamService.Tree(ctx, "", false)
+		require.NoError(t, err)
+		require.Len(t, roots, 1)
+
+		platform := roots[0]
+		assert.Equal(t, "platform", platform.Name)
+		assert.Equal(t, domain.TeamStats{Members: 1, ActiveMembers: 1}, platform.Own)
+		assert.Equal(t, domain.TeamStats{Members: 5, ActiveMembers: 4, OpenPRs: 1, MergedPRs: 1}, platform.Total)
+		require.Len(t, platform.Children, 2)
+		assert.Equal(t, "infra", platform.Children[0].Name)
+
+		storage := platform.Children[1]
+		assert.Equal(t, "storage", storage.Name)
+		assert.Equal(t, domain.TeamStats{Members: 3, ActiveMembers: 2, OpenPRs: 1, MergedPRs: 1}, storage.Total)
+
+		sub, err := teamService.Tree(ctx, "storage", false)
+		require.NoError(t, err)
+		require.Len(t, sub, 1)
+		assert.Equal(t, storage.Total, sub[0].Total)
+
+		_, err = teamService.Tree(ctx, "missing", false)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("empty squad falls back to siblings", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "hpr3", Name: "lonely", AuthorID: "h2"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"h3"}, created.AssignedReviewers)
+
+		traces, err := prService.ExplainAssignment(ctx, "hpr3")
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		assert.Equal(t, []string{"storage"}, traces[0].FallbackTeams)
+	})
+}
@@ -0,0 +1,257 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/repository/idempotency"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	team := dto.CreateTeamIn{
+		Name: "idem-team",
+		Members: []dto.UserDTO{
+			{ID: "i1", Username: "author", IsActive: true},
+			{ID: "i2", Username: "reviewer1", IsActive: true},
+			{ID: "i3", Username: "reviewer2", IsActive: true},
+			{ID: "i4", Username: "reviewer3", IsActive: true},
+		},
+	}
+	teamKey := map[string]string{"Idempotency-Key": "team-key"}
+
+	t.Run("team creation retry is replayed", func(t *testing.T) {
+		first, err := postJSONWithHeaders(srv.URL+"/team/add", team, teamKey)
+		require.NoError(t, err)
+		firstBody, _ := io.ReadAll(first.Body)
+		first.Body.Close()
+		require.Equal(t, http.StatusCreated, first.StatusCode)
+
+		retry, err := postJSONWithHeaders(srv.URL+"/team/add", team, teamKey)
+		require.NoError(t, err)
+		retryBody, _ := io.ReadAll(retry.Body)
+		retry.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, retry.StatusCode)
+		assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+		assert.Equal(t, firstBody, retryBody)
+	})
+
+	createKey := map[string]string{"Idempotency-Key": "create-key"}
+	createReq := dto.CreatePullRequestIn{ID: "pr-idem", Name: "Idempotent", AuthorID: "i1"}
+
+	var created dto.PullRequestWrapper
+
+	t.Run("create PR retry returns the same reviewers", func(t *testing.T) {
+		first, err := postJSONWithHeaders(srv.URL+"/pullRequest/create", createReq, createKey)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(first.Body).Decode(&created))
+		first.Body.Close()
+		require.Equal(t, http.StatusCreated, first.StatusCode)
+		assert.Empty(t, first.Header.Get("Idempotent-Replayed"))
+
+		retry, err := postJSONWithHeaders(srv.URL+"/pullRequest/create", createReq, createKey)
+		require.NoError(t, err)
+		var replayed dto.PullRequestWrapper
+		require.NoError(t, json.NewDecoder(retry.Body).Decode(&replayed))
+		retry.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, retry.StatusCode)
+		assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+		assert.Equal(t, first.Header.Get("ETag"), retry.Header.Get("ETag"))
+		assert.Equal(t, created.PR.Reviewers, replayed.PR.Reviewers)
+	})
+
+	t.Run("same key with different body is rejected", func(t *testing.T) {
+		other := createReq
+		other.Name = "Something else"
+
+		resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/create", other, createKey)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		var errResp domain.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, domain.ErrCodeIdempotencyReuse, errResp.Error.Code)
+	})
+
+	t.Run("without key duplicate create still fails", func(t *testing.T) {
+		resp, err := postJSON(srv.URL+"/pullRequest/create", createReq)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("client errors are replayed too", func(t *testing.T) {
+		key := map[string]string{"Idempotency-Key": "missing-author"}
+		req := dto.CreatePullRequestIn{ID: "pr-idem-404", Name: "Ghost", AuthorID: "nobody"}
+
+		for i := 0; i < 2; i++ {
+			resp, err := postJSONWithHeaders(srv.URL+"/pullRequest/create", req, key)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("expired keys are reused and purged", func(t *testing.T) {
+		repo := idempotency.NewIdempotencyRepository(db)
+		ctx := context.Background()
+		past := time.Now().Add(-time.Minute)
+
+		_, reserved, err := repo.Reserve(ctx, domain.IdempotencyRecord{Key: "old", Fingerprint: "a", ExpiresAt: past})
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		_, reserved, err = repo.Reserve(ctx, domain.IdempotencyRecord{Key: "old", Fingerprint: "b", ExpiresAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		assert.True(t, reserved, "an expired key must not block a new request")
+
+		_, reserved, err = repo.Reserve(ctx, domain.IdempotencyRecord{Key: "stale", Fingerprint: "c", ExpiresAt: past})
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		purged, err := repo.PurgeExpired(ctx, time.Now())
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, purged)
+	})
+
+	t.Run("a stuck reservation is taken over after its lease", func(t *testing.T) {
+		repo := idempotency.NewIdempotencyRepository(db)
+		ctx := context.Background()
+		expires := time.Now().Add(time.Hour)
+
+		stuck := domain.IdempotencyRecord{Key: "stuck", Fingerprint: "a", ExpiresAt: expires, LockedUntil: time.Now().Add(time.Minute)}
+		_, reserved, err := repo.Reserve(ctx, stuck)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		retry := domain.IdempotencyRecord{Key: "stuck", Fingerprint: "a", ExpiresAt: expires, LockedUntil: time.Now().Add(time.Minute)}
+		_, reserved, err = repo.Reserve(ctx, retry)
+		require.NoError(t, err)
+		require.False(t, reserved, "a live lease must keep the key")
+
+		stuck.LockedUntil = time.Now().Add(-time.Second).Truncate(time.Microsecond)
+		_, err = db.Exec(`UPDATE idempotency_keys SET locked_until = $1 WHERE idempotency_key = 'stuck'`, stuck.LockedUntil)
+		require.NoError(t, err)
+
+		_, reserved, err = repo.Reserve(ctx, retry)
+		require.NoError(t, err)
+		require.True(t, reserved, "an expired lease must not block a retry")
+
+		stuck.StatusCode = http.StatusCreated
+		assert.Error(t, repo.Complete(ctx, stuck), "the old owner must not complete the taken over key")
+		require.NoError(t, repo.Release(ctx, stuck))
+
+		retry.StatusCode = http.StatusCreated
+		require.NoError(t, repo.Complete(ctx, retry))
+	})
+}
+
+// countingStore reserves every key and counts the reservations.
+type countingStore struct {
+	reserved int
+}
+
+func (s *countingStore) Reserve(context.Context, domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	s.reserved++
+	return nil, true, nil
+}
+
+func (s *countingStore) Complete(context.Context, domain.IdempotencyRecord) error { return nil }
+
+func (s *countingStore) Release(context.Context, domain.IdempotencyRecord) error { return nil }
+
+// failingStore reserves every key but cannot store responses.
+type failingStore struct {
+	released []domain.IdempotencyRecord
+}
+
+func (s *failingStore) Reserve(context.Context, domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	return nil, true, nil
+}
+
+func (s *failingStore) Complete(context.Context, domain.IdempotencyRecord) error {
+	return errors.New("connection reset")
+}
+
+func (s *failingStore) Release(_ context.Context, rec domain.IdempotencyRecord) error {
+	s.released = append(s.released, rec)
+	return nil
+}
+
+func TestIdempotencyReleasesUnstoredResponse(t *testing.T) {
+	store := &failingStore{}
+	h := appmw.Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set(appmw.IdempotencyKeyHeader, "unstored")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	require.Len(t, store.released, 1, "a key whose response was not stored must be released")
+	assert.Equal(t, "unstored", store.released[0].Key)
+	assert.False(t, store.released[0].LockedUntil.IsZero())
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	store := &countingStore{}
+	var got int
+	h := appmw.Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = len(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(size int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/team/import", bytes.NewReader(make([]byte, size)))
+		req.Header.Set(appmw.IdempotencyKeyHeader, "upload")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(1 << 20)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1<<20, got)
+
+	got = -1
+	rec = send(1<<20 + 1)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, -1, got, "the handler must not see a cut body")
+	assert.Equal(t, 1, store.reserved)
+}
@@ -0,0 +1,277 @@
+package integration
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The JWT tests stub the SSO with generated keys and need no database.
+func TestJWTAuthentication(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	strangerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{
+			"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+		},
+	}})
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwks)
+	}))
+	defer jwksServer.Close()
+
+	opts := auth.JWTOptions{
+		Issuer:      "https://sso.example.com",
+		Audience:    "pr-service",
+		AdminGroups: []string{"pr-admins"},
+	}
+	newService := func(t *testing.T, source string, opts auth.JWTOptions) *service.AuthService {
+		keys, err := auth.NewKeySet(context.Background(), source, time.Hour)
+		require.NoError(t, err)
+		return service.NewAuthService(nil, nil, "", service.WithJWTVerifier(auth.NewJWTVerifier(keys, opts)))
+	}
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss": "https://sso.example.com",
+			"aud": []string{"pr-service", "other"},
+			"sub": "u1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	fromFile := newService(t, jwksFile, opts)
+	fromURL := newService(t, jwksServer.URL, opts)
+
+	t.Run("RS256 from a file", func(t *testing.T) {
+		id, err := fromFile.Authenticate(context.Background(), signRS256(t, rsaKey, "rsa-1", claims(nil)))
+		require.NoError(t, err)
+		assert.Equal(t, auth.Identity{TokenID: "jwt:default:u1", Scope: domain.ScopeUser, UserID: "u1", TenantID: "default"}, id)
+	})
+
+	t.Run("ES256 from a URL", func(t *testing.T) {
+		token := signES256(t, ecKey, "ec-1", claims(map[string]any{"groups": []string{"dev", "pr-admins"}}))
+		id, err := fromURL.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ScopeAdmin, id.Scope)
+		assert.Equal(t, "u1", id.UserID)
+	})
+
+	t.Run("invalid tokens are rejected", func(t *testing.T) {
+		cases := map[string]string{
+			"expired":         signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			"no exp":          signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"exp": nil})),
+			"not yet valid":   signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+			"wrong issuer":    signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"iss": "https://evil.example.com"})),
+			"wrong audience":  signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"aud": "someone-else"})),
+			"no subject":      signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"sub": nil})),
+			"unknown signer":  signRS256(t, strangerKey, "rsa-1", claims(nil)),
+			"unknown kid":     signRS256(t, rsaKey, "rsa-2", claims(nil)),
+			"key of ec kid":   signRS256(t, rsaKey, "ec-1", claims(nil)),
+			"tampered claims": tamper(t, signRS256(t, rsaKey, "rsa-1", claims(nil))),
+			"alg none":        unsigned(t, claims(nil)),
+		}
+		for name, token := range cases {
+			_, err := fromFile.Authenticate(context.Background(), token)
+			assert.ErrorIs(t, err, domain.ErrUnauthorized, name)
+		}
+	})
+
+	t.Run("jwks refresh keeps the last good keys", func(t *testing.T) {
+		var doc struct {
+			Keys []map[string]string `json:"keys"`
+		}
+		require.NoError(t, json.Unmarshal(jwks, &doc))
+		doc.Keys = append(doc.Keys, map[string]string{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": "AAAA"})
+		withUnsupported, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		var (
+			hits    atomic.Int32
+			failing atomic.Bool
+		)
+		sso := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			if failing.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write(withUnsupported)
+		}))
+		defer sso.Close()
+
+		keys, err := auth.NewKeySet(context.Background(), sso.URL, time.Millisecond)
+		require.NoError(t, err, "an unsupported key must not fail the whole set")
+		svc := service.NewAuthService(nil, nil, "", service.WithJWTVerifier(auth.NewJWTVerifier(keys, opts)))
+		token := signRS256(t, rsaKey, "rsa-1", claims(nil))
+
+		failing.Store(true)
+		time.Sleep(5 * time.Millisecond)
+		_, err = svc.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), hits.Load())
+
+		// Retries after the failure are throttled, however many requests come in.
+		time.Sleep(5 * time.Millisecond)
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := svc.Authenticate(context.Background(), token)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		_, err = svc.Authenticate(context.Background(), signRS256(t, rsaKey, "rsa-2", claims(nil)))
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		assert.Equal(t, int32(2), hits.Load())
+	})
+
+	t.Run("configured claims", func(t *testing.T) {
+		custom := opts
+		custom.UserClaim = "employee_id"
+		custom.GroupsClaim = "roles"
+		custom.UserGroups = []string{"engineering"}
+		svc := newService(t, jwksFile, custom)
+
+		id, err := svc.Authenticate(context.Background(),
+			signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"employee_id": "e42", "roles": "engineering"})))
+		require.NoError(t, err)
+		assert.Equal(t, "e42", id.UserID)
+		assert.Equal(t, domain.ScopeUser, id.Scope)
+
+		_, err = svc.Authenticate(context.Background(),
+			signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"employee_id": "e43", "roles": "sales"})))
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+
+	t.Run("tenant claim", func(t *testing.T) {
+		custom := opts
+		custom.TenantClaim = "org"
+		svc := newService(t, jwksFile, custom)
+
+		id, err := svc.Authenticate(context.Background(),
+			signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"org": "acme"})))
+		require.NoError(t, err)
+		assert.Equal(t, "acme", id.TenantID)
+		assert.Equal(t, "jwt:acme:u1", id.TokenID)
+
+		for _, org := range []any{nil, "Not Valid"} {
+			_, err = svc.Authenticate(context.Background(),
+				signRS256(t, rsaKey, "rsa-1", claims(map[string]any{"org": org})))
+			assert.ErrorIs(t, err, domain.ErrUnauthorized, org)
+		}
+	})
+
+	t.Run("identity reaches the handler", func(t *testing.T) {
+		var got auth.Identity
+		h := appmw.Authenticate(fromFile)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = auth.FromContext(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/team/get", nil)
+		req.Header.Set("Authorization", "Bearer "+signRS256(t, rsaKey, "rsa-1", claims(nil)))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "u1", got.UserID)
+
+		req = httptest.NewRequest(http.MethodGet, "/team/get", nil)
+		req.Header.Set("Authorization", "Bearer "+signRS256(t, strangerKey, "rsa-1", claims(nil)))
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signingInput(t *testing.T, header, claims map[string]any) string {
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return b64(h) + "." + b64(c)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	input := signingInput(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return input + "." + b64(sig)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	input := signingInput(t, map[string]any{"alg": "ES256", "typ": "JWT", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return input + "." + b64(sig)
+}
+
+func unsigned(t *testing.T, claims map[string]any) string {
+	return signingInput(t, map[string]any{"alg": "none"}, claims) + "."
+}
+
+// tamper swaps the claims for a different subject while keeping the
+// original signature.
+func tamper(t *testing.T, token string) string {
+	c, err := json.Marshal(map[string]any{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	header, rest, _ := strings.Cut(token, ".")
+	_, sig, _ := strings.Cut(rest, ".")
+	return header + "." + b64(c) + "." + sig
+}
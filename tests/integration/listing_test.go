@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/handlers/dto"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	teams := []dto.CreateTeamIn{
+		{Name: "alpha", Members: []dto.UserDTO{
+			{ID: "l1", Username: "Alice", IsActive: true},
+			{ID: "l2", Username: "alan", IsActive: false},
+			{ID: "l3", Username: "Bob", IsActive: true},
+		}},
+		{Name: "beta", Members: []dto.UserDTO{
+			{ID: "l4", Username: "al_x", IsActive: true},
+		}},
+		{Name: "gamma", Members: []dto.UserDTO{
+			{ID: "l5", Username: "Carol", IsActive: true},
+		}},
+	}
+	for _, team := range teams {
+		resp, err := postJSON(srv.URL+"/team/add", team)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, err := postJSON(srv.URL+"/team/archive", dto.ArchiveTeamIn{TeamName: "gamma"})
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	getJSON := func(t *testing.T, path string, out any) int {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+		return resp.StatusCode
+	}
+
+	t.Run("team list hides archived teams", func(t *testing.T) {
+		var out dto.TeamListOut
+		require.Equal(t, http.StatusOK, getJSON(t, "/team/list", &out))
+
+		require.Len(t, out.Teams, 2)
+		assert.Equal(t, 2, out.Pagination.Total)
+		assert.Equal(t, "alpha", out.Teams[0].Name)
+		assert.Equal(t, 3, out.Teams[0].MemberCount)
+		assert.Equal(t, 2, out.Teams[0].ActiveCount)
+		assert.Equal(t, "beta", out.Teams[1].Name)
+	})
+
+	t.Run("team list with archived and paging", func(t *testing.T) {
+		var out dto.TeamListOut
+		require.Equal(t, http.StatusOK, getJSON(t, "/team/list?include_archived=true&limit=1&offset=2", &out))
+
+		require.Len(t, out.Teams, 1)
+		assert.Equal(t, 3, out.Pagination.Total)
+		assert.Equal(t, "gamma", out.Teams[0].Name)
+		assert.NotNil(t, out.Teams[0].ArchivedAt)
+		assert.Zero(t, out.Teams[0].ActiveCount)
+	})
+
+	t.Run("invalid paging", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, getJSON(t, "/team/list?limit=0", nil))
+		assert.Equal(t, http.StatusBadRequest, getJSON(t, "/users/search?offset=-1", nil))
+		assert.Equal(t, http.StatusBadRequest, getJSON(t, "/users/search?is_active=maybe", nil))
+	})
+
+	t.Run("get user", func(t *testing.T) {
+		var out dto.UserWrapper
+		require.Equal(t, http.StatusOK, getJSON(t, "/users/get?user_id=l4", &out))
+		assert.Equal(t, "al_x", out.User.Username)
+		assert.Equal(t, "beta", out.User.TeamName)
+
+		assert.Equal(t, http.StatusNotFound, getJSON(t, "/users/get?user_id=nobody", nil))
+		assert.Equal(t, http.StatusBadRequest, getJSON(t, "/users/get", nil))
+	})
+
+	t.Run("search by prefix is case-insensitive and literal", func(t *testing.T) {
+		var out dto.UserSearchOut
+		require.Equal(t, http.StatusOK, getJSON(t, "/users/search?username_prefix=al", &out))
+		assert.Equal(t, 3, out.Pagination.Total)
+
+		out = dto.UserSearchOut{}
+		require.Equal(t, http.StatusOK, getJSON(t, "/users/search?username_prefix=al_", &out))
+		require.Len(t, out.Users, 1)
+		assert.Equal(t, "l4", out.Users[0].ID)
+	})
+
+	t.Run("search by team and activity", func(t *testing.T) {
+		var out dto.UserSearchOut
+		require.Equal(t, http.StatusOK, getJSON(t, "/users/search?team_name=alpha&is_active=true&limit=1", &out))
+
+		assert.Equal(t, 2, out.Pagination.Total)
+		require.Len(t, out.Users, 1)
+		assert.Equal(t, "l1", out.Users[0].ID)
+	})
+}
@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/logger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticAuthenticator struct {
+	id auth.Identity
+}
+
+func (a staticAuthenticator) Authenticate(context.Context, string) (auth.Identity, error) {
+	return a.id, nil
+}
+
+// captureLogs sends the global logger to a buffer as JSON until the test
+// ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = prev })
+
+	return &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	sc := bufio.NewScanner(buf)
+	for sc.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &line), sc.Text())
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogging(t *testing.T) {
+	buf := captureLogs(t)
+	recordSpans(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(appmw.Trace)
+	r.Use(appmw.RequestLogger)
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.Authenticate(staticAuthenticator{id: auth.Identity{
+			TokenID: "tok-1", Scope: domain.ScopeUser, UserID: "u7", TenantID: "acme",
+		}}))
+		r.Use(appmw.ResolveTenant)
+
+		r.Get("/pr/{id}", func(w http.ResponseWriter, r *http.Request) {
+			handlers.RespondInternalError(w, r, errors.New("select pull request: connection reset"))
+		})
+		r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+			handlers.RespondJSON(w, http.StatusOK, map[string]string{"ok": "yes"})
+		})
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(path, requestID string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer whatever")
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+		resp, err := (&http.Client{}).Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	get("/pr/42", "req-fail")
+	get("/ok", "req-ok")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 3)
+
+	failure, failed, succeeded := lines[0], lines[1], lines[2]
+
+	assert.Equal(t, "internal error", failure["message"])
+	assert.Equal(t, "error", failure["level"])
+	assert.Equal(t, "select pull request: connection reset", failure["error"])
+	assert.Equal(t, "req-fail", failure["request_id"])
+	assert.Equal(t, "u7", failure["user_id"])
+	assert.Equal(t, "acme", failure["tenant"])
+
+	assert.Equal(t, "request", failed["message"])
+	assert.Equal(t, "error", failed["level"])
+	assert.Equal(t, "req-fail", failed["request_id"])
+	assert.Equal(t, "/pr/{id}", failed["route"])
+	assert.Equal(t, "/pr/42", failed["path"])
+	assert.EqualValues(t, 500, failed["status"])
+	assert.Equal(t, "u7", failed["user_id"])
+	assert.Equal(t, "tok-1", failed["token_id"])
+	assert.Contains(t, failed, "latency_ms")
+	assert.Contains(t, failed, "trace_id")
+
+	assert.Equal(t, "info", succeeded["level"])
+	assert.Equal(t, "req-ok", succeeded["request_id"])
+	assert.EqualValues(t, 200, succeeded["status"])
+	assert.NotEqual(t, failed["trace_id"], succeeded["trace_id"])
+}
+
+func TestContextLogger(t *testing.T) {
+	buf := captureLogs(t)
+
+	ctx := context.Background()
+	logger.AddFields(ctx, func(c zerolog.Context) zerolog.Context { return c.Str("ignored", "x") })
+	logger.FromContext(ctx).Info().Msg("global")
+
+	ctx = logger.WithContext(ctx, log.Logger.With().Str("request_id", "r1").Logger())
+	logger.AddFields(ctx, func(c zerolog.Context) zerolog.Context { return c.Str("user_id", "u1") })
+	logger.FromContext(ctx).Info().Msg("scoped")
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2)
+	assert.NotContains(t, lines[0], "ignored")
+	assert.NotContains(t, lines[0], "request_id")
+	assert.Equal(t, "r1", lines[1]["request_id"])
+	assert.Equal(t, "u1", lines[1]["user_id"])
+}
@@ -0,0 +1,226 @@
+package integration
+
+import (
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+	userService := service.NewUserService(userTeamRepo, prRepo, txManager, prService)
+
+	setup := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "core", Members: []domain.User{
+			{ID: "m1", Username: "author", IsActive: true},
+			{ID: "m2", Username: "reviewer1", IsActive: true},
+			{ID: "m3", Username: "reviewer2", IsActive: true},
+			{ID: "m4", Username: "reviewer3", IsActive: true},
+		}}))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "infra", Members: []domain.User{
+			{ID: "m5", Username: "ops", IsActive: true},
+		}}))
+	}
+
+	t.Run("team add updates existing users", func(t *testing.T) {
+		setup(t)
+
+		_, _, err := teamService.RemoveMember(ctx, "core", "m4", false)
+		require.NoError(t, err)
+
+		err = teamService.Create(ctx, domain.Team{Name: "platform", Members: []domain.User{
+			{ID: "m4", Username: "renamed", IsActive: false},
+		}})
+		require.NoError(t, err)
+
+		user, err := userTeamRepo.GetUserByID(ctx, "m4")
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", user.Username)
+		assert.Equal(t, "platform", user.TeamName)
+		assert.False(t, user.IsActive)
+	})
+
+	t.Run("add members to existing team", func(t *testing.T) {
+		setup(t)
+
+		team, err := teamService.AddMembers(ctx, "infra", []domain.User{
+			{ID: "m6", Username: "newbie", IsActive: true},
+			{ID: "m5", Username: "ops", IsActive: true},
+		})
+		require.NoError(t, err)
+		assert.Len(t, team.Members, 2)
+
+		_, err = teamService.AddMembers(ctx, "missing", []domain.User{{ID: "m7", Username: "ghost", IsActive: true}})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("members of other teams are not taken over", func(t *testing.T) {
+		setup(t)
+
+		_, err := teamService.AddMembers(ctx, "infra", []domain.User{{ID: "m2", Username: "reviewer1", IsActive: true}})
+		assert.ErrorIs(t, err, domain.ErrUserInOtherTeam)
+
+		err = teamService.Create(ctx, domain.Team{Name: "platform", Members: []domain.User{
+			{ID: "m3", Username: "reviewer2", IsActive: true},
+		}})
+		assert.ErrorIs(t, err, domain.ErrUserInOtherTeam)
+
+		for _, id := range []string{"m2", "m3"} {
+			user, err := userTeamRepo.GetUserByID(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "core", user.TeamName)
+		}
+		_, err = teamService.Get(ctx, "platform")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("sync moves users with reassigning reviews", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-m5", Name: "Sync", AuthorID: "m1"})
+		require.NoError(t, err)
+		moved := created.AssignedReviewers[0]
+
+		diff, reassigned, err := teamService.Sync(ctx, domain.Team{Name: "infra", Members: []domain.User{
+			{ID: "m5", Username: "ops", IsActive: true},
+			{ID: moved, Username: "moved", IsActive: true},
+		}}, true)
+		require.NoError(t, err)
+		require.Len(t, diff.Added, 1)
+		assert.Equal(t, moved, diff.Added[0].ID)
+		require.Len(t, reassigned, 1)
+		assert.NotContains(t, reassigned[0].AssignedReviewers, moved)
+
+		user, err := userTeamRepo.GetUserByID(ctx, moved)
+		require.NoError(t, err)
+		assert.Equal(t, "infra", user.TeamName)
+	})
+
+	t.Run("remove member detaches and deactivates", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-m1", Name: "Remove", AuthorID: "m1"})
+		require.NoError(t, err)
+		removed := created.AssignedReviewers[0]
+
+		_, _, err = teamService.RemoveMember(ctx, "infra", removed, false)
+		assert.ErrorIs(t, err, domain.ErrNotTeamMember)
+
+		user, reassigned, err := teamService.RemoveMember(ctx, "core", removed, true)
+		require.NoError(t, err)
+		assert.Empty(t, user.TeamName)
+		assert.False(t, user.IsActive)
+		require.Len(t, reassigned, 1)
+		assert.NotContains(t, reassigned[0].AssignedReviewers, removed)
+
+		team, err := teamService.Get(ctx, "core")
+		require.NoError(t, err)
+		assert.Len(t, team.Members, 3)
+
+		stored, err := userTeamRepo.GetUserByID(ctx, removed)
+		require.NoError(t, err)
+		assert.Empty(t, stored.TeamName)
+	})
+
+	t.Run("removing the last member keeps the team", func(t *testing.T) {
+		setup(t)
+
+		_, _, err := teamService.RemoveMember(ctx, "infra", "m5", false)
+		require.NoError(t, err)
+
+		team, err := teamService.Get(ctx, "infra")
+		require.NoError(t, err)
+		assert.Empty(t, team.Members)
+
+		_, err = prService.Create(ctx, domain.PullRequestCreate{ID: "pr-m2", Name: "Orphan", AuthorID: "m5"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("move user with reassigning reviews", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-m3", Name: "Move", AuthorID: "m1"})
+		require.NoError(t, err)
+		moved := created.AssignedReviewers[0]
+
+		user, reassigned, err := userService.Move(ctx, moved, "infra", true)
+		require.NoError(t, err)
+		assert.Equal(t, "infra", user.TeamName)
+		assert.True(t, user.IsActive)
+		require.Len(t, reassigned, 1)
+		assert.NotContains(t, reassigned[0].AssignedReviewers, moved)
+
+		_, _, err = userService.Move(ctx, moved, "missing", false)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("move rolls back without candidates", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-m4", Name: "Stuck", AuthorID: "m1"})
+		require.NoError(t, err)
+
+		for _, id := range []string{"m2", "m3", "m4"} {
+			if !contains(created.AssignedReviewers, id) {
+				_, err = userService.SetActive(ctx, id, false)
+				require.NoError(t, err)
+			}
+		}
+
+		moved := created.AssignedReviewers[0]
+		_, _, err = userService.Move(ctx, moved, "infra", true)
+		require.ErrorIs(t, err, domain.ErrNoCandidate)
+
+		user, err := userTeamRepo.GetUserByID(ctx, moved)
+		require.NoError(t, err)
+		assert.Equal(t, "core", user.TeamName)
+	})
+
+	t.Run("update username", func(t *testing.T) {
+		setup(t)
+
+		user, err := userService.UpdateUsername(ctx, "m2", "bob")
+		require.NoError(t, err)
+		assert.Equal(t, "bob", user.Username)
+		assert.Equal(t, "core", user.TeamName)
+
+		_, err = userService.UpdateUsername(ctx, "nobody", "bob")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	metricshand "pr-service/internal/handlers/metrics_handlers"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/metrics"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+	"pr-service/internal/tenant"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, reg *metrics.Registry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.WriteText(context.Background(), &buf))
+	return buf.String()
+}
+
+func TestMetricsExposition(t *testing.T) {
+	t.Run("counters and gauges", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		c := metrics.NewCounterVec("jobs_total", "Jobs done.\nSecond line.", "kind")
+		g := metrics.NewGaugeVec("queue_depth", "", "queue")
+		reg.MustRegister(c, g)
+
+		c.Inc("b")
+		c.Add(2.5, "a")
+		c.Add(-1, "a")
+		c.Inc(`quote"back\slash` + "\nnl")
+		g.Set(7, "q")
+		g.Add(-2, "q")
+
+		assert.Equal(t, `# HELP jobs_total Jobs done.\nSecond line.
+# TYPE jobs_total counter
+jobs_total{kind="a"} 2.5
+jobs_total{kind="b"} 1
+jobs_total{kind="quote\"back\\slash\nnl"} 1
+# TYPE queue_depth gauge
+queue_depth{queue="q"} 5
+`, scrape(t, reg))
+	})
+
+	t.Run("histogram buckets are cumulative", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		h := metrics.NewHistogramVec("latency_seconds", "Latency.", []float64{0.5, 0.1, 1}, "route")
+		reg.MustRegister(h)
+
+		for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+			h.Observe(v, "/x")
+		}
+
+		assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/x",le="0.1"} 2
+latency_seconds_bucket{route="/x",le="0.5"} 3
+latency_seconds_bucket{route="/x",le="1"} 3
+latency_seconds_bucket{route="/x",le="+Inf"} 4
+latency_seconds_sum{route="/x"} 2.45
+latency_seconds_count{route="/x"} 4
+`, scrape(t, reg))
+	})
+
+	t.Run("failing collector is skipped", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		c := metrics.NewCounterVec("ok_total", "")
+		c.Inc()
+		reg.MustRegister(metrics.CollectorFunc(func(context.Context) ([]metrics.Family, error) {
+			return nil, errors.New("boom")
+		}), c)
+
+		var buf bytes.Buffer
+		err := reg.WriteText(context.Background(), &buf)
+		assert.ErrorContains(t, err, "boom")
+		assert.Equal(t, "# TYPE ok_total counter\nok_total 1\n", buf.String())
+	})
+
+	t.Run("business counters are labelled by tenant", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		rec := metrics.NewRecorder(reg)
+
+		rec.PRCreated(context.Background())
+		rec.PRCreated(tenant.WithID(context.Background(), "acme"))
+		rec.NoCandidate(context.Background())
+
+		out := scrape(t, reg)
+		assert.Contains(t, out, `pr_service_pull_requests_created_total{tenant="acme"} 1`)
+		assert.Contains(t, out, `pr_service_pull_requests_created_total{tenant="default"} 1`)
+		assert.Contains(t, out, `pr_service_no_candidate_total{tenant="default"} 1`)
+	})
+
+	t.Run("middleware uses route patterns", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		rec := metrics.NewRecorder(reg)
+
+		r := chi.NewRouter()
+		r.Use(appmw.Metrics(rec))
+		r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "fine")
+		})
+		metricshand.NewMetricsHandler(reg).RegisterRoutes(r)
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+
+		for _, path := range []string{"/items/1", "/items/2", "/ok", "/nope"} {
+			resp, err := (&http.Client{}).Get(srv.URL + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		resp, err := (&http.Client{}).Get(srv.URL + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, metrics.ContentType, resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		out := string(body)
+
+		assert.Contains(t, out, `pr_service_http_requests_total{method="GET",route="/items/{id}",status="418"} 2`)
+		assert.Contains(t, out, `pr_service_http_requests_total{method="GET",route="/ok",status="200"} 1`)
+		assert.Contains(t, out, `pr_service_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+		assert.Contains(t, out, `pr_service_http_request_duration_seconds_count{method="GET",route="/items/{id}"} 2`)
+		assert.NotContains(t, out, "/items/1")
+	})
+}
+
+func TestMetricsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	reg := metrics.NewRegistry()
+	rec := metrics.NewRecorder(reg)
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager, service.WithMetrics(rec))
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	reg.MustRegister(metrics.DBStats(db), metrics.OpenReviews(prRepo))
+
+	require.NoError(t, teamService.Create(ctx, domain.Team{Name: "metrics", Members: []domain.User{
+		{ID: "m1", Username: "author", IsActive: true},
+		{ID: "m2", Username: "rev1", IsActive: true},
+		{ID: "m3", Username: "rev2", IsActive: true},
+	}}))
+
+	created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "mpr1", Name: "one", AuthorID: "m1"})
+	require.NoError(t, err)
+	require.Len(t, created.AssignedReviewers, 2)
+	_, err = prService.Create(ctx, domain.PullRequestCreate{ID: "mpr2", Name: "two", AuthorID: "m1"})
+	require.NoError(t, err)
+
+	_, err = prService.Reassign(ctx, "mpr1", created.AssignedReviewers[0], 0)
+	require.ErrorIs(t, err, domain.ErrNoCandidate)
+
+	_, err = prService.Merge(ctx, "mpr2", 0)
+	require.NoError(t, err)
+	_, err = prService.Merge(ctx, "mpr2", 0)
+	require.NoError(t, err)
+
+	out := scrape(t, reg)
+	for _, line := range []string{
+		`pr_service_pull_requests_created_total{tenant="default"} 2`,
+		`pr_service_pull_requests_merged_total{tenant="default"} 1`,
+		`pr_service_no_candidate_total{tenant="default"} 1`,
+		`pr_service_open_reviews{tenant="default",team="metrics"} 2`,
+		"# TYPE pr_service_db_open_connections gauge",
+	} {
+		assert.Contains(t, out, line)
+	}
+	assert.False(t, strings.Contains(out, "pr_service_reviewer_reassignments_total{"), "no reassignment succeeded")
+}
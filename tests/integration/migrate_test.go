@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/migrations"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("embedded", func(t *testing.T) {
+		list, err := dbtx.LoadMigrations(migrations.FS)
+		require.NoError(t, err)
+		require.Len(t, list, 14)
+
+		for i, m := range list {
+			assert.Equal(t, uint(i+1), m.Version, "versions must have no gaps")
+			assert.NotEmpty(t, m.Up, m.Name)
+			assert.NotEmpty(t, m.Down, m.Name)
+		}
+		assert.Equal(t, "create_team", list[0].Name)
+		assert.Equal(t, "tenants", list[12].Name)
+	})
+
+	t.Run("missing down file", func(t *testing.T) {
+		_, err := dbtx.LoadMigrations(fstest.MapFS{
+			"001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"002_b.up.sql":   {Data: []byte("SELECT 1;")},
+		})
+		assert.ErrorContains(t, err, "2_b")
+	})
+
+	t.Run("bad names", func(t *testing.T) {
+		_, err := dbtx.LoadMigrations(fstest.MapFS{"001_a.sql": {Data: []byte("SELECT 1;")}})
+		assert.Error(t, err)
+
+		_, err = dbtx.LoadMigrations(fstest.MapFS{"first.up.sql": {Data: []byte("SELECT 1;")}})
+		assert.Error(t, err)
+	})
+}
+
+func TestMigratorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	admin, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	// A schema of its own, so migrating down does not touch the tables the
+	// other tests use.
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	defer func() { _, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE") }()
+
+	open := func(t *testing.T) *sqlx.DB {
+		db, err := sqlx.Open("postgres", dbDSN+" search_path="+schema)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	ctx := context.Background()
+	db := open(t)
+	migrator, err := dbtx.NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+
+	version := func(t *testing.T) uint {
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, status.Dirty)
+		return status.Version
+	}
+
+	t.Run("up down round trip", func(t *testing.T) {
+		assert.Equal(t, uint(0), version(t))
+
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Len(t, applied, 14)
+		assert.Equal(t, uint(14), version(t))
+
+		applied, err = migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+
+		reverted, err := migrator.Down(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, reverted, 2)
+		assert.Equal(t, uint(14), reverted[0].Version)
+		assert.Equal(t, uint(12), version(t))
+
+		reverted, err = migrator.Down(ctx, 100)
+		require.NoError(t, err)
+		assert.Len(t, reverted, 12)
+		assert.Equal(t, uint(0), version(t))
+
+		var tables int
+		require.NoError(t, db.Get(&tables, `
+			SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = $1 AND table_name <> 'schema_migrations'`, schema))
+		assert.Zero(t, tables, "down migrations must drop everything")
+
+		_, err = migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint(14), version(t))
+	})
+
+	t.Run("dirty schema needs force", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE schema_migrations SET dirty = TRUE`)
+		require.NoError(t, err)
+
+		_, err = migrator.Up(ctx)
+		assert.ErrorIs(t, err, dbtx.ErrDirtySchema)
+
+		require.NoError(t, migrator.Force(ctx, 14))
+		assert.Equal(t, uint(14), version(t))
+
+		assert.Error(t, migrator.Force(ctx, 99))
+	})
+
+	t.Run("concurrent replicas", func(t *testing.T) {
+		_, err := migrator.Down(ctx, 14)
+		require.NoError(t, err)
+
+		const replicas = 4
+		var (
+			wg    sync.WaitGroup
+			mu    sync.Mutex
+			total int
+			errs  []error
+		)
+		for range replicas {
+			m, err := dbtx.NewMigrator(open(t), migrations.FS)
+			require.NoError(t, err)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				applied, err := m.Up(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+				total += len(applied)
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Empty(t, errs)
+		assert.Equal(t, 14, total, "every migration is applied exactly once")
+		assert.Equal(t, uint(14), version(t))
+	})
+}
@@ -0,0 +1,390 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+	"pr-service/internal/orgchart"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgchartFormats(t *testing.T) {
+	const csvFile = `team_name,parent_name,user_id,username,role,is_active
+fintech,,u1,Alice,lead,true
+payments,fintech,u2,Bob,,
+payments,fintech,u3,Carol,observer,false
+mobile,fintech,,,,
+`
+	const yamlFile = `teams:
+  - team_name: fintech
+    members:
+      - {user_id: u1, username: Alice, role: lead, is_active: true}
+  - team_name: payments
+    parent_name: fintech
+    members:
+      - {user_id: u2, username: Bob}
+      - {user_id: u3, username: Carol, role: observer, is_active: false}
+  - team_name: mobile
+    parent_name: fintech
+`
+
+	want := []domain.Team{
+		{Name: "fintech", Members: []domain.User{
+			{ID: "u1", Username: "Alice", IsActive: true, TeamName: "fintech", Role: domain.RoleLead},
+		}},
+		{Name: "payments", ParentName: "fintech", Members: []domain.User{
+			{ID: "u2", Username: "Bob", IsActive: true, TeamName: "payments"},
+			{ID: "u3", Username: "Carol", IsActive: false, TeamName: "payments", Role: domain.RoleObserver},
+		}},
+		{Name: "mobile", ParentName: "fintech", Members: []domain.User{}},
+	}
+
+	teamsOf := func(imported []domain.ImportTeam) []domain.Team {
+		teams := make([]domain.Team, len(imported))
+		for i, t := range imported {
+			teams[i] = t.Team
+		}
+		return teams
+	}
+
+	for format, file := range map[string]string{orgchart.CSV: csvFile, orgchart.YAML: yamlFile} {
+		t.Run(format, func(t *testing.T) {
+			imported, err := orgchart.Decode(format, strings.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, want, teamsOf(imported))
+
+			var buf bytes.Buffer
+			require.NoError(t, orgchart.Encode(format, &buf, want))
+
+			again, err := orgchart.Decode(format, &buf)
+			require.NoError(t, err)
+			assert.Equal(t, want, teamsOf(again))
+		})
+	}
+
+	t.Run("csv without parent_name keeps parents", func(t *testing.T) {
+		imported, err := orgchart.Decode(orgchart.CSV, strings.NewReader("team_name,user_id,username\npayments,u1,Alice\n"))
+		require.NoError(t, err)
+		require.Len(t, imported, 1)
+		assert.True(t, imported[0].KeepParent)
+
+		imported, err = orgchart.Decode(orgchart.CSV, strings.NewReader(csvFile))
+		require.NoError(t, err)
+		for _, team := range imported {
+			assert.False(t, team.KeepParent, team.Name)
+		}
+	})
+
+	t.Run("csv lines", func(t *testing.T) {
+		imported, err := orgchart.Decode(orgchart.CSV, strings.NewReader(csvFile))
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 3, 5}, []int{imported[0].Line, imported[1].Line, imported[2].Line})
+	})
+
+	t.Run("format of", func(t *testing.T) {
+		assert.Equal(t, orgchart.CSV, orgchart.FormatOf(".csv"))
+		assert.Equal(t, orgchart.CSV, orgchart.FormatOf("text/csv; charset=utf-8"))
+		assert.Equal(t, orgchart.YAML, orgchart.FormatOf(".yml"))
+		assert.Equal(t, orgchart.YAML, orgchart.FormatOf("application/yaml"))
+		assert.Empty(t, orgchart.FormatOf("application/json"))
+	})
+
+	t.Run("row issues are reported together", func(t *testing.T) {
+		file := `team_name,parent_name,user_id,username,role,is_active
+payments,fintech,u1,Alice,,
+payments,platform,u2,Bob,boss,
+payments,fintech,u1,Again,,
+,,u4,Nobody,,
+loop,loop,u5,Self,,maybe
+`
+		_, err := orgchart.Decode(orgchart.CSV, strings.NewReader(file))
+
+		var issues domain.ImportIssues
+		require.ErrorAs(t, err, &issues)
+
+		lines := make([]int, len(issues))
+		for i, issue := range issues {
+			lines[i] = issue.Line
+		}
+		assert.Equal(t, []int{3, 3, 4, 5, 6, 6}, lines)
+		assert.Contains(t, issues[0].Message, "conflicts")
+		assert.Equal(t, "u2", issues[1].UserID)
+		assert.Contains(t, issues[2].Message, "line 2")
+		assert.Contains(t, issues[3].Message, "team_name")
+		assert.Contains(t, issues[4].Message, "own parent")
+		assert.Contains(t, issues[5].Message, "is_active")
+	})
+
+	t.Run("unknown keys and columns", func(t *testing.T) {
+		_, err := orgchart.Decode(orgchart.CSV, strings.NewReader("team_name,email\nbackend,a@b.c\n"))
+		var issues domain.ImportIssues
+		require.ErrorAs(t, err, &issues)
+		assert.Contains(t, issues[0].Message, `"email"`)
+
+		_, err = orgchart.Decode(orgchart.YAML, strings.NewReader("teams:\n  - team_name: backend\n    lead: u1\n"))
+		require.ErrorAs(t, err, &issues)
+		assert.Equal(t, 3, issues[0].Line)
+		assert.Contains(t, issues[0].Message, `"lead"`)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		for _, format := range []string{orgchart.CSV, orgchart.YAML} {
+			_, err := orgchart.Decode(format, strings.NewReader(""))
+			var issues domain.ImportIssues
+			require.ErrorAs(t, err, &issues, format)
+		}
+
+		_, err := orgchart.Decode(orgchart.CSV, strings.NewReader("team_name,user_id,username\n"))
+		var issues domain.ImportIssues
+		require.ErrorAs(t, err, &issues)
+		assert.Contains(t, issues[0].Message, "no teams")
+	})
+}
+
+func TestTeamImportIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	importFile := func(t *testing.T, format, query, file string) *http.Response {
+		resp, err := http.Post(srv.URL+"/team/import?format="+format+query, orgchart.ContentType(format), strings.NewReader(file))
+		require.NoError(t, err)
+		return resp
+	}
+	decodeImport := func(t *testing.T, resp *http.Response) dto.ImportOut {
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var out dto.ImportOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out
+	}
+	export := func(t *testing.T, format string) string {
+		resp, err := http.Get(srv.URL + "/team/export?format=" + format)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, orgchart.ContentType(format), resp.Header.Get("Content-Type"))
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	setup := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		resp, err := postJSON(srv.URL+"/team/add", dto.CreateTeamIn{Name: "platform", Members: []dto.UserDTO{
+			{ID: "i1", Username: "head", IsActive: true},
+		}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, err = postJSON(srv.URL+"/team/add", dto.CreateTeamIn{Name: "payments", ParentName: "platform", Members: []dto.UserDTO{
+			{ID: "i2", Username: "Bob", IsActive: true},
+			{ID: "i3", Username: "Carol", IsActive: true},
+		}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// fintech is created and becomes the parent of payments, which loses
+	// Carol and gains Dave; the parent is listed after its child.
+	const file = `team_name,parent_name,user_id,username,role,is_active
+payments,fintech,i2,Bob,lead,true
+payments,fintech,i4,Dave,,
+fintech,,i5,Eve,,
+`
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		setup(t)
+		before := export(t, orgchart.CSV)
+
+		out := decodeImport(t, importFile(t, orgchart.CSV, "&dry_run=true", file))
+		assert.True(t, out.DryRun)
+		assert.Equal(t, 2, out.Changed)
+
+		require.Len(t, out.Teams, 2)
+		payments := out.Teams[0]
+		assert.Equal(t, "payments", payments.TeamName)
+		assert.False(t, payments.Created)
+		assert.True(t, payments.ParentChanged)
+		assert.Equal(t, "platform", payments.OldParentName)
+		assert.Equal(t, "fintech", payments.ParentName)
+		require.Len(t, payments.Added, 1)
+		assert.Equal(t, "i4", payments.Added[0].ID)
+		require.Len(t, payments.Updated, 1)
+		assert.Equal(t, "i2", payments.Updated[0].ID)
+		require.Len(t, payments.Removed, 1)
+		assert.Equal(t, "i3", payments.Removed[0].ID)
+		assert.True(t, out.Teams[1].Created)
+
+		assert.Equal(t, before, export(t, orgchart.CSV))
+	})
+
+	t.Run("dry run reports reviews without reassigning them", func(t *testing.T) {
+		setup(t)
+
+		// Carol is the only possible reviewer of Bob's PR and leaves payments.
+		resp, err := postJSON(srv.URL+"/pullRequest/create", dto.CreatePullRequestIn{ID: "imp-pr", Name: "Import", AuthorID: "i2"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		out := decodeImport(t, importFile(t, orgchart.CSV, "&dry_run=true&reassign_reviews=true", file))
+		require.Len(t, out.Teams[0].ReassignedPRs, 1)
+		assert.Equal(t, "imp-pr", out.Teams[0].ReassignedPRs[0].ID)
+		assert.Equal(t, []string{"i3"}, out.Teams[0].ReassignedPRs[0].Reviewers)
+
+		resp, err = http.Get(srv.URL + "/pullRequest/get?pull_request_id=imp-pr")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var pr dto.PullRequestWrapper
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&pr))
+		assert.Equal(t, []string{"i3"}, pr.PR.Reviewers)
+	})
+
+	t.Run("dry run reports reviews of moved members but not of new ones", func(t *testing.T) {
+		setup(t)
+
+		post := func(path string, payload any) {
+			resp, err := postJSON(srv.URL+path, payload)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Less(t, resp.StatusCode, 300, path)
+		}
+
+		// Frank reviews Grace's PR in ops; Heidi reviews Ivan's PR and then
+		// leaves ops-2 without handing it over.
+		post("/team/add", dto.CreateTeamIn{Name: "ops", Members: []dto.UserDTO{
+			{ID: "i6", Username: "Frank", IsActive: true},
+			{ID: "i7", Username: "Grace", IsActive: true},
+		}})
+		post("/pullRequest/create", dto.CreatePullRequestIn{ID: "ops-pr", Name: "Ops", AuthorID: "i7"})
+		post("/team/add", dto.CreateTeamIn{Name: "ops-2", Members: []dto.UserDTO{
+			{ID: "i8", Username: "Heidi", IsActive: true},
+			{ID: "i9", Username: "Ivan", IsActive: true},
+		}})
+		post("/pullRequest/create", dto.CreatePullRequestIn{ID: "ops-2-pr", Name: "Ops 2", AuthorID: "i9"})
+		post("/team/removeMember", dto.RemoveTeamMemberIn{TeamName: "ops-2", UserID: "i8"})
+
+		const moves = `team_name,parent_name,user_id,username,role,is_active
+payments,platform,i2,Bob,,true
+payments,platform,i3,Carol,,true
+payments,platform,i6,Frank,,true
+payments,platform,i8,Heidi,,true
+`
+		out := decodeImport(t, importFile(t, orgchart.CSV, "&dry_run=true&reassign_reviews=true", moves))
+		require.Len(t, out.Teams, 1)
+		require.Len(t, out.Teams[0].ReassignedPRs, 1, "only the moved member hands reviews over")
+		assert.Equal(t, "ops-pr", out.Teams[0].ReassignedPRs[0].ID)
+	})
+
+	t.Run("apply and round trip", func(t *testing.T) {
+		setup(t)
+
+		out := decodeImport(t, importFile(t, orgchart.CSV, "", file))
+		assert.False(t, out.DryRun)
+		assert.Equal(t, 2, out.Changed)
+
+		exported := export(t, orgchart.CSV)
+		assert.Equal(t, `team_name,parent_name,user_id,username,role,is_active
+fintech,,i5,Eve,member,true
+payments,fintech,i2,Bob,lead,true
+payments,fintech,i4,Dave,member,true
+platform,,i1,head,member,true
+`, exported)
+
+		for _, format := range []string{orgchart.CSV, orgchart.YAML} {
+			out := decodeImport(t, importFile(t, format, "", export(t, format)))
+			assert.Zero(t, out.Changed, format)
+		}
+	})
+
+	t.Run("file without parent_name keeps parents", func(t *testing.T) {
+		setup(t)
+
+		out := decodeImport(t, importFile(t, orgchart.CSV, "", "team_name,user_id,username\npayments,i2,Bob\npayments,i3,Carol\nplatform,i1,head\n"))
+		assert.Zero(t, out.Changed)
+
+		exported := export(t, orgchart.CSV)
+		assert.Contains(t, exported, "payments,platform,i2,Bob,member,true\n")
+	})
+
+	t.Run("round trip with an archived parent", func(t *testing.T) {
+		setup(t)
+
+		resp, err := postJSON(srv.URL+"/team/archive", dto.ArchiveTeamIn{TeamName: "platform"})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		for _, format := range []string{orgchart.CSV, orgchart.YAML} {
+			exported := export(t, format)
+			assert.NotContains(t, exported, "platform", format)
+
+			decodeImport(t, importFile(t, format, "", exported))
+			assert.Equal(t, exported, export(t, format), format)
+		}
+	})
+
+	t.Run("issues reject the whole file", func(t *testing.T) {
+		setup(t)
+		before := export(t, orgchart.CSV)
+
+		resp := importFile(t, orgchart.YAML, "", `teams:
+  - team_name: payments
+    parent_name: missing
+    members:
+      - {user_id: i2, username: Bob}
+  - team_name: platform
+    parent_name: payments
+`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var errResp dto.ImportErrorOut
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, domain.ErrCodeInvalidImport, errResp.Error.Code)
+		require.Len(t, errResp.Error.Issues, 1)
+		assert.Equal(t, 2, errResp.Error.Issues[0].Line)
+		assert.Equal(t, "payments", errResp.Error.Issues[0].TeamName)
+
+		resp = importFile(t, orgchart.CSV, "", "team_name,parent_name\nplatform,payments\n")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Contains(t, errResp.Error.Issues[0].Message, domain.ErrTeamCycle.Error())
+
+		assert.Equal(t, before, export(t, orgchart.CSV))
+	})
+
+	t.Run("format is required", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/team/import", "application/json", strings.NewReader("{}"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
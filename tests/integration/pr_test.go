@@ -1,7 +1,9 @@
 package integration
 
 import (
-	"context"
+	"fmt"
+	"math/rand"
+	dbtx "pr-service/internal/db"
 	"pr-service/internal/repository/pr"
 	"pr-service/internal/repository/user_team"
 	"testing"
@@ -23,7 +25,7 @@ func TestPRIntegration(t *testing.T) {
 	dbDSN, err := getPostgresDSN()
 	require.NoError(t, err)
 
-	ctx := context.Background()
+	ctx := adminContext()
 
 	db, err := sqlx.Open("postgres", dbDSN)
 	require.NoError(t, err)
@@ -32,10 +34,11 @@ func TestPRIntegration(t *testing.T) {
 	require.NoError(t, cleanupDatabase(db))
 
 	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
 	prRepo := pr.NewPRRepository(db)
 
-	teamService := service.NewTeamService(userTeamRepo)
-	prService := service.NewPRService(prRepo, userTeamRepo)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
 
 	members := []domain.User{
 		{ID: "u20", Username: "dev1", IsActive: true},
@@ -63,11 +66,11 @@ func TestPRIntegration(t *testing.T) {
 		_, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-101", Name: "Fix bug", AuthorID: "u21"})
 		require.NoError(t, err)
 
-		mergedPR, err := prService.Merge(ctx, "pr-101")
+		mergedPR, err := prService.Merge(ctx, "pr-101", 0)
 		require.NoError(t, err)
 		assert.Equal(t, domain.PRStatusMerged, mergedPR.Status)
 
-		mergedPR2, err := prService.Merge(ctx, "pr-101")
+		mergedPR2, err := prService.Merge(ctx, "pr-101", 0)
 		require.NoError(t, err)
 		assert.Equal(t, domain.PRStatusMerged, mergedPR2.Status)
 	})
@@ -91,7 +94,7 @@ func TestPRIntegration(t *testing.T) {
 
 		oldReviewerID := createPR.AssignedReviewers[0]
 
-		reassignedPR, err := prService.Reassign(ctx, "pr-103", oldReviewerID)
+		reassignedPR, err := prService.Reassign(ctx, "pr-103", oldReviewerID, 0)
 		require.NoError(t, err)
 		assert.NotContains(t, reassignedPR.AssignedReviewers, oldReviewerID)
 	})
@@ -114,7 +117,7 @@ func TestPRIntegration(t *testing.T) {
 
 		oldReviewerID := createPR.AssignedReviewers[0]
 
-		reassignedPR, err := prService.Reassign(ctx, "pr-104", oldReviewerID)
+		reassignedPR, err := prService.Reassign(ctx, "pr-104", oldReviewerID, 0)
 
 		assert.Error(t, err)
 		assert.Nil(t, reassignedPR)
@@ -145,3 +148,166 @@ func TestPRIntegration(t *testing.T) {
 	err = cleanupDatabase(db)
 	require.NoError(t, err)
 }
+
+func TestPairingDiversityIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, service.NewPRService(prRepo, userTeamRepo, txManager))
+
+	members := []domain.User{
+		{ID: "u60", Username: "author", IsActive: true},
+		{ID: "u61", Username: "reviewer1", IsActive: true},
+		{ID: "u62", Username: "reviewer2", IsActive: true},
+		{ID: "u63", Username: "reviewer3", IsActive: true},
+		{ID: "u64", Username: "reviewer4", IsActive: true},
+	}
+
+	run := func(seed int64) [][]string {
+		require.NoError(t, cleanupDatabase(db))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "diverse-team", Members: members}))
+
+		prService := service.NewPRService(prRepo, userTeamRepo, txManager,
+			service.WithPairingDiversity(service.PairingDiversity{Window: 5, Decay: 0.5}),
+			service.WithRandSource(rand.NewSource(seed)),
+		)
+
+		var picks [][]string
+		for i := 0; i < 6; i++ {
+			created, err := prService.Create(ctx, domain.PullRequestCreate{
+				ID:       fmt.Sprintf("pr-div-%d", i),
+				Name:     "Diverse",
+				AuthorID: "u60",
+			})
+			require.NoError(t, err)
+			require.Len(t, created.AssignedReviewers, 2)
+			assert.NotContains(t, created.AssignedReviewers, "u60")
+			assert.NotEqual(t, created.AssignedReviewers[0], created.AssignedReviewers[1])
+
+			picks = append(picks, created.AssignedReviewers)
+		}
+
+		return picks
+	}
+
+	t.Run("same seed gives same assignments", func(t *testing.T) {
+		first := run(42)
+		second := run(42)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("reviewers rotate across consecutive PRs", func(t *testing.T) {
+		picks := run(7)
+
+		seen := make(map[string]bool)
+		for _, reviewers := range picks {
+			for _, id := range reviewers {
+				seen[id] = true
+			}
+		}
+		assert.Len(t, seen, 4)
+	})
+
+	require.NoError(t, cleanupDatabase(db))
+}
+
+func TestExplainAssignmentIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager, service.WithRandSource(rand.NewSource(1)))
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	members := []domain.User{
+		{ID: "u70", Username: "author", IsActive: true},
+		{ID: "u71", Username: "reviewer1", IsActive: true},
+		{ID: "u72", Username: "reviewer2", IsActive: true},
+		{ID: "u73", Username: "reviewer3", IsActive: true},
+		{ID: "u74", Username: "away", IsActive: false},
+	}
+	require.NoError(t, teamService.Create(ctx, domain.Team{Name: "explain-team", Members: members}))
+
+	created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-300", Name: "Explain", AuthorID: "u70"})
+	require.NoError(t, err)
+
+	t.Run("create is traced", func(t *testing.T) {
+		traces, err := prService.ExplainAssignment(ctx, "pr-300")
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+
+		trace := traces[0]
+		assert.Equal(t, domain.AssignmentCreate, trace.Kind)
+		assert.Equal(t, created.AssignedReviewers, trace.Picked)
+		require.Len(t, trace.Candidates, 5)
+
+		reasons := make(map[string]domain.ExclusionReason)
+		for _, c := range trace.Candidates {
+			reasons[c.UserID] = c.Excluded
+		}
+		assert.Equal(t, domain.ExcludedAuthor, reasons["u70"])
+		assert.Equal(t, domain.ExcludedInactive, reasons["u74"])
+		assert.Empty(t, reasons["u71"])
+	})
+
+	t.Run("reassign is traced", func(t *testing.T) {
+		oldReviewerID := created.AssignedReviewers[0]
+
+		reassigned, err := prService.Reassign(ctx, "pr-300", oldReviewerID, 0)
+		require.NoError(t, err)
+
+		traces, err := prService.ExplainAssignment(ctx, "pr-300")
+		require.NoError(t, err)
+		require.Len(t, traces, 2)
+
+		trace := traces[1]
+		assert.Equal(t, domain.AssignmentReassign, trace.Kind)
+		assert.Equal(t, oldReviewerID, trace.ReplacedReviewerID)
+		require.Len(t, trace.Picked, 1)
+		assert.Contains(t, reassigned.AssignedReviewers, trace.Picked[0])
+	})
+
+	t.Run("same source replays the same picks", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "explain-team", Members: members}))
+
+		replay := service.NewPRService(prRepo, userTeamRepo, txManager, service.WithRandSource(rand.NewSource(1)))
+		again, err := replay.Create(ctx, domain.PullRequestCreate{ID: "pr-300", Name: "Explain", AuthorID: "u70"})
+		require.NoError(t, err)
+		assert.Equal(t, created.AssignedReviewers, again.AssignedReviewers)
+	})
+
+	t.Run("unknown PR", func(t *testing.T) {
+		_, err := prService.ExplainAssignment(ctx, "pr-missing")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	require.NoError(t, cleanupDatabase(db))
+}
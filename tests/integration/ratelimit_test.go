@@ -0,0 +1,199 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"pr-service/internal/auth"
+	"pr-service/internal/domain"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/ratelimit"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The rate limit tests run the middleware in front of a stub handler and
+// need no database.
+func TestRateLimit(t *testing.T) {
+	policy := ratelimit.Policy{
+		Default: ratelimit.Limit{Requests: 3, Per: time.Minute},
+		Routes: map[string]ratelimit.Limit{
+			"/pullRequest/create": {Requests: 1, Per: time.Minute, Burst: 2},
+		},
+	}
+
+	newHandler := func() http.Handler {
+		limited := appmw.RateLimit(ratelimit.NewMemory(), policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		// Stands in for Authenticate: the token comes from a test header.
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tok := r.Header.Get("X-Test-Token"); tok != "" {
+				r = r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{TokenID: tok, Scope: domain.ScopeUser}))
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+	send := func(h http.Handler, path, token, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if token != "" {
+			req.Header.Set("X-Test-Token", token)
+		}
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("route limit with burst", func(t *testing.T) {
+		h := newHandler()
+
+		first := send(h, "/pullRequest/create", "ci", "")
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+		assert.Empty(t, first.Header().Get("Retry-After"))
+
+		require.Equal(t, http.StatusOK, send(h, "/pullRequest/create", "ci", "").Code)
+
+		rejected := send(h, "/pullRequest/create", "ci", "")
+		require.Equal(t, http.StatusTooManyRequests, rejected.Code)
+		assert.Contains(t, rejected.Body.String(), domain.ErrCodeRateLimited)
+		assert.Equal(t, "0", rejected.Header().Get("X-RateLimit-Remaining"))
+
+		retry, err := strconv.Atoi(rejected.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.InDelta(t, 60, retry, 1)
+		reset, err := strconv.Atoi(rejected.Header().Get("X-RateLimit-Reset"))
+		require.NoError(t, err)
+		assert.InDelta(t, 120, reset, 1)
+	})
+
+	t.Run("buckets are per token and per route", func(t *testing.T) {
+		h := newHandler()
+
+		for range 2 {
+			require.Equal(t, http.StatusOK, send(h, "/pullRequest/create", "ci", "").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, send(h, "/pullRequest/create", "ci", "").Code)
+
+		assert.Equal(t, http.StatusOK, send(h, "/pullRequest/create", "someone-else", "").Code)
+		assert.Equal(t, http.StatusOK, send(h, "/team/get", "ci", "").Code)
+	})
+
+	t.Run("default routes share a bucket", func(t *testing.T) {
+		h := newHandler()
+
+		for _, path := range []string{"/team/get", "/users/get", "/stats"} {
+			require.Equal(t, http.StatusOK, send(h, path, "reader", "").Code, path)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, send(h, "/team/list", "reader", "").Code)
+	})
+
+	t.Run("anonymous clients are keyed by IP", func(t *testing.T) {
+		h := newHandler()
+
+		for range 3 {
+			require.Equal(t, http.StatusOK, send(h, "/team/get", "", "10.0.0.1:1234").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, send(h, "/team/get", "", "10.0.0.1:5678").Code)
+		assert.Equal(t, http.StatusOK, send(h, "/team/get", "", "10.0.0.2:1234").Code)
+	})
+
+	t.Run("unauthenticated requests are limited before authentication", func(t *testing.T) {
+		authn := &rejectingAuthenticator{}
+
+		r := chi.NewRouter()
+		r.Use(appmw.RateLimitByIP(ratelimit.NewMemory(), ratelimit.Limit{Requests: 2, Per: time.Minute}))
+		r.Use(appmw.Authenticate(authn))
+		r.Get("/team/get", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		get := func(token string) int {
+			req := httptest.NewRequest(http.MethodGet, "/team/get", nil)
+			req.RemoteAddr = "10.0.0.3:1234"
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, get(""))
+		assert.Equal(t, http.StatusUnauthorized, get("prs_guess"))
+		assert.Equal(t, http.StatusTooManyRequests, get("prs_guess"))
+		assert.Equal(t, http.StatusTooManyRequests, get(""))
+		assert.Equal(t, 1, authn.calls, "rejected requests must not reach authentication")
+	})
+
+	t.Run("clients behind a trusted proxy get their own buckets", func(t *testing.T) {
+		trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+		limited := appmw.RateLimitByIP(ratelimit.NewMemory(), ratelimit.Limit{Requests: 1, Per: time.Minute})
+		h := appmw.RealIP(trusted)(limited(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+		get := func(remoteAddr string, forwardedFor ...string) int {
+			req := httptest.NewRequest(http.MethodGet, "/team/get", nil)
+			req.RemoteAddr = remoteAddr
+			for _, v := range forwardedFor {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, get("10.0.0.9:1234", "203.0.113.1"))
+		assert.Equal(t, http.StatusOK, get("10.0.0.9:1234", "203.0.113.2"))
+		assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.8:1234", "203.0.113.1, 10.0.0.7"),
+			"trusted hops are skipped")
+		assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.9:1234", "198.51.100.1", "203.0.113.2"),
+			"only the right-most untrusted hop counts")
+
+		assert.Equal(t, http.StatusOK, get("192.0.2.1:1234", "203.0.113.3"))
+		assert.Equal(t, http.StatusTooManyRequests, get("192.0.2.1:1234", "203.0.113.4"),
+			"an untrusted peer must not pick its address")
+	})
+
+	t.Run("tokens refill", func(t *testing.T) {
+		limiter := ratelimit.NewMemory()
+		limit := ratelimit.Limit{Requests: 1, Per: 50 * time.Millisecond}
+
+		d, err := limiter.Allow(context.Background(), "k", limit)
+		require.NoError(t, err)
+		require.True(t, d.Allowed)
+
+		d, err = limiter.Allow(context.Background(), "k", limit)
+		require.NoError(t, err)
+		require.False(t, d.Allowed)
+		assert.LessOrEqual(t, d.RetryAfter, 50*time.Millisecond)
+
+		time.Sleep(60 * time.Millisecond)
+
+		d, err = limiter.Allow(context.Background(), "k", limit)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	})
+}
+
+// rejectingAuthenticator refuses every token and counts the attempts.
+type rejectingAuthenticator struct {
+	calls int
+}
+
+func (a *rejectingAuthenticator) Authenticate(context.Context, string) (auth.Identity, error) {
+	a.calls++
+	return auth.Identity{}, domain.ErrUnauthorized
+}
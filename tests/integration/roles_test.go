@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	setup := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "core", Members: []domain.User{
+			{ID: "r1", Username: "author", IsActive: true},
+			{ID: "r2", Username: "lead", IsActive: true, Role: domain.RoleLead},
+			{ID: "r3", Username: "dev", IsActive: true},
+			{ID: "r4", Username: "watcher", IsActive: true, Role: domain.RoleObserver},
+		}}))
+	}
+
+	t.Run("roles are stored and default to member", func(t *testing.T) {
+		setup(t)
+
+		team, err := teamService.Get(ctx, "core")
+		require.NoError(t, err)
+
+		roles := make(map[string]domain.Role, len(team.Members))
+		for _, m := range team.Members {
+			roles[m.ID] = m.Role
+		}
+		assert.Equal(t, map[string]domain.Role{
+			"r1": domain.RoleMember,
+			"r2": domain.RoleLead,
+			"r3": domain.RoleMember,
+			"r4": domain.RoleObserver,
+		}, roles)
+	})
+
+	t.Run("omitted role keeps the current one", func(t *testing.T) {
+		setup(t)
+
+		team, err := teamService.AddMembers(ctx, "core", []domain.User{
+			{ID: "r2", Username: "lead", IsActive: true},
+			{ID: "r4", Username: "watcher", IsActive: true, Role: domain.RoleMember},
+			{ID: "r5", Username: "newbie", IsActive: true},
+		})
+		require.NoError(t, err)
+
+		roles := make(map[string]domain.Role, len(team.Members))
+		for _, m := range team.Members {
+			roles[m.ID] = m.Role
+		}
+		assert.Equal(t, domain.RoleLead, roles["r2"])
+		assert.Equal(t, domain.RoleMember, roles["r4"])
+		assert.Equal(t, domain.RoleMember, roles["r5"])
+	})
+
+	t.Run("observers are never picked", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "rpr1", Name: "feature", AuthorID: "r1"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"r2", "r3"}, created.AssignedReviewers)
+
+		traces, err := prService.ExplainAssignment(ctx, "rpr1")
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		for _, c := range traces[0].Candidates {
+			if c.UserID == "r4" {
+				assert.Equal(t, domain.ExcludedObserver, c.Excluded)
+			}
+		}
+	})
+
+	t.Run("requested lead is added as an extra reviewer", func(t *testing.T) {
+		setup(t)
+
+		_, _, err := teamService.SetRole(ctx, "core", "r2", domain.RoleMember, false)
+		require.NoError(t, err)
+		_, err = teamService.AddMembers(ctx, "core", []domain.User{
+			{ID: "r5", Username: "another", IsActive: true},
+			{ID: "r6", Username: "boss", IsActive: true, Role: domain.RoleLead},
+		})
+		require.NoError(t, err)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{
+			ID: "rpr2", Name: "feature", AuthorID: "r1", RequestLead: true,
+		})
+		require.NoError(t, err)
+		assert.Contains(t, created.AssignedReviewers, "r6")
+		assert.GreaterOrEqual(t, len(created.AssignedReviewers), 2)
+		assert.LessOrEqual(t, len(created.AssignedReviewers), 3)
+
+		traces, err := prService.ExplainAssignment(ctx, "rpr2")
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		assert.Equal(t, "r6", traces[0].LeadID)
+	})
+
+	t.Run("set role", func(t *testing.T) {
+		setup(t)
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "rpr3", Name: "feature", AuthorID: "r1"})
+		require.NoError(t, err)
+		require.Contains(t, created.AssignedReviewers, "r3")
+
+		_, err = teamService.AddMembers(ctx, "core", []domain.User{{ID: "r7", Username: "spare", IsActive: true}})
+		require.NoError(t, err)
+
+		user, reassigned, err := teamService.SetRole(ctx, "core", "r3", domain.RoleObserver, true)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RoleObserver, user.Role)
+		require.Len(t, reassigned, 1)
+		assert.NotContains(t, reassigned[0].AssignedReviewers, "r3")
+		assert.Contains(t, reassigned[0].AssignedReviewers, "r7")
+
+		_, _, err = teamService.SetRole(ctx, "core", "r3", domain.Role("owner"), false)
+		require.ErrorIs(t, err, domain.ErrInvalidRole)
+
+		_, _, err = teamService.SetRole(ctx, "core", "nobody", domain.RoleLead, false)
+		require.ErrorIs(t, err, domain.ErrNotTeamMember)
+	})
+}
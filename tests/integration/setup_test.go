@@ -1,17 +1,63 @@
 package integration
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
+	"pr-service/internal/auth"
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	authhand "pr-service/internal/handlers/auth_handlers"
+	appmw "pr-service/internal/handlers/middleware"
+	prhand "pr-service/internal/handlers/pr_handlers"
+	teamhand "pr-service/internal/handlers/team_handlers"
+	userhand "pr-service/internal/handlers/user_handlers"
+	"pr-service/internal/repository/idempotency"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/token"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+
+	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 
 	_ "github.com/lib/pq"
 )
 
+// testAdminToken is the bootstrap token newTestRouter accepts. Requests sent
+// through http.DefaultClient carry it unless they set Authorization
+// themselves.
+const testAdminToken = "integration-admin-token"
+
+func init() {
+	http.DefaultClient.Transport = bearerTransport{token: testAdminToken}
+}
+
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// adminContext is the context for calling services directly, as an admin.
+func adminContext() context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{TokenID: "test", Scope: domain.ScopeAdmin})
+}
+
 func getPostgresDSN() (string, error) {
 	err := load()
 	if err != nil {
@@ -36,6 +82,8 @@ func cleanupDatabase(db *sqlx.DB) error {
         TRUNCATE TABLE pull_requests CASCADE;
         TRUNCATE TABLE users CASCADE;
         TRUNCATE TABLE teams CASCADE;
+        TRUNCATE TABLE idempotency_keys;
+        TRUNCATE TABLE api_tokens;
     `)
 	return err
 }
@@ -47,3 +95,50 @@ func load() error {
 
 	return godotenv.Load(envPath)
 }
+
+func newTestRouter(db *sqlx.DB) http.Handler {
+	teamRepo := user_team.NewUserTeamRepository(db)
+	prRepo := pr.NewPRRepository(db)
+	txManager := dbtx.NewTxManager(db)
+
+	authService := service.NewAuthService(token.NewTokenRepository(db), teamRepo, testAdminToken)
+
+	r := chi.NewRouter()
+	r.Use(appmw.Authenticate(authService))
+	r.Use(appmw.ResolveTenant)
+	prService := service.NewPRService(prRepo, teamRepo, txManager)
+
+	authhand.NewAuthHandler(authService).RegisterRoutes(r)
+
+	r.Group(func(r chi.Router) {
+		r.Use(appmw.Idempotency(idempotency.NewIdempotencyRepository(db), time.Hour, time.Minute))
+
+		teamhand.NewTeamHandler(service.NewTeamService(teamRepo, prRepo, txManager, prService)).RegisterRoutes(r)
+		userhand.NewUserHandler(service.NewUserService(teamRepo, prRepo, txManager, prService)).RegisterRoutes(r)
+		prhand.NewPRHandler(prService).RegisterRoutes(r)
+	})
+
+	return r
+}
+
+func postJSON(url string, payload any) (*http.Response, error) {
+	return postJSONWithHeaders(url, payload, nil)
+}
+
+func postJSONWithHeaders(url string, payload any, headers map[string]string) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return http.DefaultClient.Do(req)
+}
@@ -1,7 +1,8 @@
 package integration
 
 import (
-	"context"
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/repository/pr"
 	"pr-service/internal/repository/user_team"
 	"testing"
 
@@ -22,14 +23,18 @@ func TestTeamIntegration(t *testing.T) {
 	dbDSN, err := getPostgresDSN()
 	require.NoError(t, err)
 
-	ctx := context.Background()
+	ctx := adminContext()
 
 	db, err := sqlx.Open("postgres", dbDSN)
 	require.NoError(t, err)
 	defer db.Close()
 
 	userTeamRepo := user_team.NewUserTeamRepository(db)
-	teamService := service.NewTeamService(userTeamRepo)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+	userService := service.NewUserService(userTeamRepo, prRepo, txManager, prService)
 
 	t.Run("create and get team", func(t *testing.T) {
 		members := []domain.User{
@@ -53,7 +58,7 @@ func TestTeamIntegration(t *testing.T) {
 		assert.Len(t, fetchedTeam.Members, 2)
 	})
 
-	t.Run("idempotent team creation", func(t *testing.T) {
+	t.Run("duplicate team creation fails", func(t *testing.T) {
 		require.NoError(t, cleanupDatabase(db))
 
 		members1 := []domain.User{
@@ -63,25 +68,154 @@ func TestTeamIntegration(t *testing.T) {
 		err = teamService.Create(ctx, domain.Team{Name: "frontend", Members: members1})
 		require.NoError(t, err)
 
-		team1, err := teamService.Get(ctx, "frontend")
-		assert.NotNil(t, team1)
-		require.NoError(t, err)
-
-		assert.Len(t, team1.Members, 1)
-
 		members2 := []domain.User{
 			{ID: "u10", Username: "alice", IsActive: true},
 			{ID: "u11", Username: "charlie", IsActive: true},
 		}
 
 		err = teamService.Create(ctx, domain.Team{Name: "frontend", Members: members2})
+		require.ErrorIs(t, err, domain.ErrTeamAlreadyExists)
+
+		team, err := teamService.Get(ctx, "frontend")
 		require.NoError(t, err)
+		assert.Len(t, team.Members, 1)
+
+		user, err := userTeamRepo.GetUserByID(ctx, "u11")
+		require.NoError(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("sync creates missing team", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		diff, _, err := teamService.Sync(ctx, domain.Team{Name: "mobile", Members: []domain.User{
+			{ID: "u20", Username: "dana", IsActive: true},
+		}}, false)
+		require.NoError(t, err)
+
+		assert.True(t, diff.Created)
+		require.Len(t, diff.Added, 1)
+		assert.Empty(t, diff.Updated)
+		assert.Empty(t, diff.Removed)
+	})
+
+	t.Run("sync reports diff", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		err = teamService.Create(ctx, domain.Team{Name: "data", Members: []domain.User{
+			{ID: "u30", Username: "erin", IsActive: true},
+			{ID: "u31", Username: "frank", IsActive: true},
+			{ID: "u32", Username: "grace", IsActive: true},
+		}})
+		require.NoError(t, err)
+
+		diff, _, err := teamService.Sync(ctx, domain.Team{Name: "data", Members: []domain.User{
+			{ID: "u30", Username: "erin", IsActive: true},
+			{ID: "u31", Username: "franklin", IsActive: true},
+			{ID: "u33", Username: "heidi", IsActive: true},
+		}}, false)
+		require.NoError(t, err)
+
+		assert.False(t, diff.Created)
+		require.Len(t, diff.Added, 1)
+		assert.Equal(t, "u33", diff.Added[0].ID)
+		require.Len(t, diff.Updated, 1)
+		assert.Equal(t, "u31", diff.Updated[0].ID)
+		require.Len(t, diff.Removed, 1)
+		assert.Equal(t, "u32", diff.Removed[0].ID)
+		assert.Len(t, diff.Team.Members, 3)
+
+		removed, err := userTeamRepo.GetUserByID(ctx, "u32")
+		require.NoError(t, err)
+		assert.Empty(t, removed.TeamName)
+		assert.False(t, removed.IsActive)
+
+		again, _, err := teamService.Sync(ctx, domain.Team{Name: "data", Members: diff.Team.Members}, false)
+		require.NoError(t, err)
+		assert.Empty(t, again.Added)
+		assert.Empty(t, again.Updated)
+		assert.Empty(t, again.Removed)
+	})
+
+	archivable := func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "legacy", Members: []domain.User{
+			{ID: "u50", Username: "author", IsActive: true},
+			{ID: "u51", Username: "reviewer1", IsActive: true},
+			{ID: "u52", Username: "reviewer2", IsActive: true},
+		}}))
+	}
+
+	t.Run("archive makes team read-only", func(t *testing.T) {
+		archivable(t)
 
-		team2, err := teamService.Get(ctx, "frontend")
-		assert.NotNil(t, team2)
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-legacy", Name: "Old", AuthorID: "u50"})
 		require.NoError(t, err)
 
-		assert.Len(t, team2.Members, 2)
+		team, err := teamService.Archive(ctx, "legacy")
+		require.NoError(t, err)
+		require.True(t, team.IsArchived())
+		for _, m := range team.Members {
+			assert.False(t, m.IsActive)
+		}
+
+		again, err := teamService.Archive(ctx, "legacy")
+		require.NoError(t, err)
+		assert.Equal(t, team.ArchivedAt.Unix(), again.ArchivedAt.Unix())
+
+		_, err = teamService.AddMembers(ctx, "legacy", []domain.User{{ID: "u53", Username: "late", IsActive: true}})
+		assert.ErrorIs(t, err, domain.ErrTeamArchived)
+
+		_, _, err = teamService.RemoveMember(ctx, "legacy", "u51", false)
+		assert.ErrorIs(t, err, domain.ErrTeamArchived)
+
+		_, err = userService.SetActive(ctx, "u51", true)
+		assert.ErrorIs(t, err, domain.ErrTeamArchived)
+
+		_, err = prService.Create(ctx, domain.PullRequestCreate{ID: "pr-legacy-2", Name: "New", AuthorID: "u50"})
+		assert.ErrorIs(t, err, domain.ErrTeamArchived)
+
+		history, err := prService.Get(ctx, "pr-legacy")
+		require.NoError(t, err)
+		assert.Equal(t, created.AssignedReviewers, history.AssignedReviewers)
+	})
+
+	t.Run("archive unknown team", func(t *testing.T) {
+		_, err := teamService.Archive(ctx, "missing")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("delete refuses with open PRs unless forced", func(t *testing.T) {
+		archivable(t)
+
+		_, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-doomed", Name: "Doomed", AuthorID: "u50"})
+		require.NoError(t, err)
+
+		_, err = teamService.Delete(ctx, "legacy", false)
+		require.ErrorIs(t, err, domain.ErrTeamHasOpenPRs)
+
+		openPRs, err := teamService.Delete(ctx, "legacy", true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, openPRs)
+
+		_, err = teamService.Get(ctx, "legacy")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		_, err = prService.Get(ctx, "pr-doomed")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("delete without open PRs", func(t *testing.T) {
+		archivable(t)
+
+		_, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-done", Name: "Done", AuthorID: "u50"})
+		require.NoError(t, err)
+		_, err = prService.Merge(ctx, "pr-done", 0)
+		require.NoError(t, err)
+
+		openPRs, err := teamService.Delete(ctx, "legacy", false)
+		require.NoError(t, err)
+		assert.Zero(t, openPRs)
 	})
 
 	err = cleanupDatabase(db)
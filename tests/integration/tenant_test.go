@@ -0,0 +1,245 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-service/internal/domain"
+	"pr-service/internal/handlers/dto"
+	appmw "pr-service/internal/handlers/middleware"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolationIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	srv := httptest.NewServer(newTestRouter(db))
+	defer srv.Close()
+
+	// call sends the request with exactly the given token and tenant header.
+	call := func(t *testing.T, method, path, token, tenantID string, body any) (int, []byte) {
+		var payload bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&payload).Encode(body))
+		}
+		req, err := http.NewRequest(method, srv.URL+path, &payload)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if tenantID != "" {
+			req.Header.Set(appmw.TenantHeader, tenantID)
+		}
+
+		resp, err := (&http.Client{}).Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var out bytes.Buffer
+		_, err = out.ReadFrom(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, out.Bytes()
+	}
+	mustCall := func(t *testing.T, want int, method, path, token, tenantID string, body any) []byte {
+		code, out := call(t, method, path, token, tenantID, body)
+		require.Equal(t, want, code, "%s %s: %s", method, path, out)
+		return out
+	}
+	issue := func(t *testing.T, tenantID string, req dto.IssueTokenIn) dto.IssueTokenOut {
+		var out dto.IssueTokenOut
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusCreated, http.MethodPost, "/auth/issueToken", testAdminToken, tenantID, req), &out))
+		return out
+	}
+
+	// Both tenants get a team called "shared" with the same user ids, so
+	// equal keys must not collide.
+	for _, tc := range []struct{ tenant, team, prefix string }{
+		{"acme", "a-team", "a"},
+		{"globex", "g-team", "g"},
+	} {
+		mustCall(t, http.StatusCreated, http.MethodPost, "/team/add", testAdminToken, tc.tenant, dto.CreateTeamIn{
+			Name: tc.team,
+			Members: []dto.UserDTO{
+				{ID: tc.prefix + "1", Username: tc.tenant + "-author", IsActive: true},
+				{ID: tc.prefix + "2", Username: tc.tenant + "-rev1", IsActive: true},
+				{ID: tc.prefix + "3", Username: tc.tenant + "-rev2", IsActive: true},
+			},
+		})
+		mustCall(t, http.StatusCreated, http.MethodPost, "/team/add", testAdminToken, tc.tenant, dto.CreateTeamIn{
+			Name:    "shared",
+			Members: []dto.UserDTO{{ID: "s1", Username: tc.tenant + "-shared", IsActive: true}},
+		})
+		mustCall(t, http.StatusCreated, http.MethodPost, "/pullRequest/create", testAdminToken, tc.tenant, dto.CreatePullRequestIn{
+			ID: tc.prefix + "-pr", Name: "feature", AuthorID: tc.prefix + "1",
+		})
+	}
+
+	acmeAdmin := issue(t, "acme", dto.IssueTokenIn{Name: "acme admin", Scope: "admin"})
+	assert.Equal(t, "acme", acmeAdmin.Token.TenantID)
+	globexUser := issue(t, "globex", dto.IssueTokenIn{Name: "globex user", Scope: "user", UserID: "g2"})
+	assert.Equal(t, "globex", globexUser.Token.TenantID)
+
+	var globexPR dto.PullRequestWrapper
+	require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet,
+		"/pullRequest/get?pull_request_id=g-pr", testAdminToken, "globex", nil), &globexPR))
+
+	token := acmeAdmin.Secret
+
+	t.Run("reads see only the own tenant", func(t *testing.T) {
+		for _, path := range []string{
+			"/team/get?team_name=g-team",
+			"/users/get?user_id=g1",
+			"/users/getReview?user_id=g2",
+			"/pullRequest/get?pull_request_id=g-pr",
+			"/pullRequest/explainAssignment?pull_request_id=g-pr",
+			"/team/tree?team_name=g-team",
+		} {
+			code, out := call(t, http.MethodGet, path, token, "", nil)
+			assert.Equal(t, http.StatusNotFound, code, "%s: %s", path, out)
+		}
+
+		var team dto.TeamWrapper
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/team/get?team_name=shared", token, "", nil), &team))
+		require.Len(t, team.Team.Members, 1)
+		assert.Equal(t, "acme-shared", team.Team.Members[0].Username)
+
+		var list dto.TeamListOut
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/team/list", token, "", nil), &list))
+		assert.Equal(t, 2, list.Pagination.Total)
+		for _, tm := range list.Teams {
+			assert.Contains(t, []string{"a-team", "shared"}, tm.Name)
+		}
+
+		var tree dto.TeamTreeOut
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/team/tree", token, "", nil), &tree))
+		require.Len(t, tree.Teams, 2)
+		for _, node := range tree.Teams {
+			assert.NotEqual(t, "g-team", node.Name)
+			assert.LessOrEqual(t, node.Total.Members, 3)
+		}
+
+		var users dto.UserSearchOut
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/users/search", token, "", nil), &users))
+		assert.Equal(t, 4, users.Pagination.Total)
+		for _, u := range users.Users {
+			assert.Contains(t, []string{"a1", "a2", "a3", "s1"}, u.ID)
+		}
+
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/users/search?team_name=g-team", token, "", nil), &users))
+		assert.Zero(t, users.Pagination.Total)
+
+		var stats struct {
+			Total int `json:"total_pull_requests"`
+		}
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/stats", token, "", nil), &stats))
+		assert.Equal(t, 1, stats.Total)
+	})
+
+	t.Run("writes cannot reach the other tenant", func(t *testing.T) {
+		for _, tc := range []struct {
+			path string
+			body any
+		}{
+			{"/team/addMembers", dto.AddTeamMembersIn{TeamName: "g-team", Members: []dto.UserDTO{{ID: "a9", Username: "x", IsActive: true}}}},
+			{"/team/removeMember", dto.RemoveTeamMemberIn{TeamName: "g-team", UserID: "g2"}},
+			{"/team/setRole", dto.SetTeamRoleIn{TeamName: "g-team", UserID: "g2", Role: "lead"}},
+			{"/team/setParent", dto.SetTeamParentIn{TeamName: "g-team"}},
+			{"/team/setParent", dto.SetTeamParentIn{TeamName: "a-team", ParentName: "g-team"}},
+			{"/team/archive", dto.ArchiveTeamIn{TeamName: "g-team"}},
+			{"/team/delete", dto.DeleteTeamIn{TeamName: "g-team", Force: true}},
+			{"/deactivate?team_name=g-team", nil},
+			{"/users/setIsActive", dto.SetUserActiveIn{UserID: "g2", IsActive: false}},
+			{"/users/move", dto.MoveUserIn{UserID: "g2", TeamName: "a-team"}},
+			{"/users/move", dto.MoveUserIn{UserID: "a2", TeamName: "g-team"}},
+			{"/users/update", dto.UpdateUserIn{UserID: "g2", Username: "hijacked"}},
+			{"/pullRequest/create", dto.CreatePullRequestIn{ID: "x-pr", Name: "x", AuthorID: "g1"}},
+			{"/pullRequest/merge", dto.MergePullRequest{ID: "g-pr"}},
+			{"/pullRequest/reassign", dto.ReassignReviewerRequest{PullRequestID: "g-pr", OldReviewerID: globexPR.PR.Reviewers[0]}},
+			{"/auth/issueToken", dto.IssueTokenIn{Name: "steal", Scope: "user", UserID: "g1"}},
+			{"/auth/revokeToken", dto.RevokeTokenIn{TokenID: globexUser.Token.ID}},
+		} {
+			code, out := call(t, http.MethodPost, tc.path, token, "", tc.body)
+			assert.Equal(t, http.StatusNotFound, code, "%s: %s", tc.path, out)
+		}
+
+		// Names are per tenant: syncing "g-team" creates acme's own team.
+		var synced dto.SyncTeamOut
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusCreated, http.MethodPost, "/team/sync", token, "", dto.SyncTeamIn{
+			Name: "g-team", Members: []dto.UserDTO{{ID: "g1", Username: "acme-g1", IsActive: true}},
+		}), &synced))
+		assert.True(t, synced.Created)
+	})
+
+	t.Run("token tenant cannot be overridden", func(t *testing.T) {
+		code, out := call(t, http.MethodGet, "/team/get?team_name=g-team", token, "globex", nil)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Contains(t, string(out), domain.ErrTenantMismatch.Error())
+
+		code, _ = call(t, http.MethodGet, "/team/get?team_name=a-team", globexUser.Secret, "acme", nil)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = call(t, http.MethodGet, "/team/get?team_name=a-team", token, "acme", nil)
+		assert.Equal(t, http.StatusOK, code)
+
+		code, _ = call(t, http.MethodGet, "/team/list", testAdminToken, "Not A Tenant", nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("idempotency keys are per tenant", func(t *testing.T) {
+		for _, tenantID := range []string{"acme", "globex"} {
+			body, err := json.Marshal(dto.CreateTeamIn{
+				Name:    "idem-" + tenantID,
+				Members: []dto.UserDTO{{ID: "i-" + tenantID, Username: "idem", IsActive: true}},
+			})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/team/add", bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(appmw.TenantHeader, tenantID)
+			req.Header.Set(appmw.IdempotencyKeyHeader, "same-key")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusCreated, resp.StatusCode, tenantID)
+			assert.Empty(t, resp.Header.Get("Idempotent-Replayed"), tenantID)
+		}
+	})
+
+	t.Run("other tenant is untouched", func(t *testing.T) {
+		var team dto.TeamWrapper
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/team/get?team_name=g-team", testAdminToken, "globex", nil), &team))
+		assert.Nil(t, team.Team.ArchivedAt)
+		require.Len(t, team.Team.Members, 3)
+		for _, m := range team.Team.Members {
+			assert.True(t, m.IsActive, m.ID)
+			assert.Equal(t, "member", m.Role, m.ID)
+			assert.NotEqual(t, "hijacked", m.Username)
+		}
+
+		var pr dto.PullRequestWrapper
+		require.NoError(t, json.Unmarshal(mustCall(t, http.StatusOK, http.MethodGet, "/pullRequest/get?pull_request_id=g-pr", testAdminToken, "globex", nil), &pr))
+		assert.Equal(t, dto.PRStatus("OPEN"), pr.PR.Status)
+		assert.ElementsMatch(t, globexPR.PR.Reviewers, pr.PR.Reviewers)
+
+		code, _ := call(t, http.MethodGet, "/team/get?team_name=g-team", globexUser.Secret, "", nil)
+		assert.Equal(t, http.StatusOK, code, "globex token must still work")
+	})
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbtx "pr-service/internal/db"
+	"pr-service/internal/domain"
+	appmw "pr-service/internal/handlers/middleware"
+	"pr-service/internal/repository/pr"
+	"pr-service/internal/repository/user_team"
+	"pr-service/internal/service"
+	"pr-service/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in
+// memory until the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return recorder
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(appmw.Trace)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "inner", attribute.String("k", "v"))
+		span.End()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/items/42", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", parent)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+
+	resp, err := (&http.Client{}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", resp.Header.Get(appmw.TraceIDHeader))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	inner, server := spans[0], spans[1]
+	assert.Equal(t, "inner", inner.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), inner.Parent().SpanID())
+
+	assert.Equal(t, "GET /items/{id}", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Equal(t, "req-42", spanAttr(server, "http.request.id"))
+	assert.Equal(t, "/items/{id}", spanAttr(server, "http.route"))
+	assert.Equal(t, "204", spanAttr(server, "http.response.status_code"))
+}
+
+func TestTracingIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dbDSN, err := getPostgresDSN()
+	require.NoError(t, err)
+
+	ctx := adminContext()
+
+	db, err := sqlx.Open("postgres", dbDSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanupDatabase(db))
+
+	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
+	prRepo := pr.NewPRRepository(db)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+
+	require.NoError(t, teamService.Create(ctx, domain.Team{Name: "traced", Members: []domain.User{
+		{ID: "t1", Username: "author", IsActive: true},
+		{ID: "t2", Username: "reviewer", IsActive: true},
+	}}))
+
+	recorder := recordSpans(t)
+
+	_, err = prService.Create(ctx, domain.PullRequestCreate{ID: "tpr1", Name: "traced", AuthorID: "t1"})
+	require.NoError(t, err)
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		byName[s.Name()] = s
+	}
+
+	root, ok := byName["PRService.Create"]
+	require.True(t, ok)
+	assert.Equal(t, "tpr1", spanAttr(root, "pr.id"))
+
+	tx, ok := byName["db.Transaction"]
+	require.True(t, ok)
+	assert.Equal(t, root.SpanContext().SpanID(), tx.Parent().SpanID())
+
+	for _, name := range []string{
+		"user_team.UserTeamRepository.GetUserByID",
+		"user_team.UserTeamRepository.GetByName",
+		"pr.PRRepository.Create",
+	} {
+		span, ok := byName[name]
+		if !assert.True(t, ok, name) {
+			continue
+		}
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID(), name)
+		assert.Equal(t, "postgresql", spanAttr(span, "db.system.name"), name)
+		assert.NotEmpty(t, spanAttr(span, "db.query.text"), name)
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	dbtx "pr-service/internal/db"
 	"pr-service/internal/repository/pr"
 	"pr-service/internal/repository/user_team"
 	"testing"
@@ -24,7 +25,7 @@ func TestUserIntegration(t *testing.T) {
 	dbDSN, err := getPostgresDSN()
 	require.NoError(t, err)
 
-	ctx := context.Background()
+	ctx := adminContext()
 
 	db, err := sqlx.Open("postgres", dbDSN)
 	require.NoError(t, err)
@@ -33,10 +34,12 @@ func TestUserIntegration(t *testing.T) {
 	require.NoError(t, cleanupDatabase(db))
 
 	userTeamRepo := user_team.NewUserTeamRepository(db)
+	txManager := dbtx.NewTxManager(db)
 	prRepo := pr.NewPRRepository(db)
 
-	teamService := service.NewTeamService(userTeamRepo)
-	userService := service.NewUserService(userTeamRepo, prRepo)
+	prService := service.NewPRService(prRepo, userTeamRepo, txManager)
+	teamService := service.NewTeamService(userTeamRepo, prRepo, txManager, prService)
+	userService := service.NewUserService(userTeamRepo, prRepo, txManager, prService)
 
 	members := []domain.User{
 		{ID: "u40", Username: "user1", IsActive: true},
@@ -61,6 +64,69 @@ func TestUserIntegration(t *testing.T) {
 		assert.Nil(t, user)
 	})
 
+	t.Run("deactivate and reassign reviews", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		members := []domain.User{
+			{ID: "u80", Username: "author", IsActive: true},
+			{ID: "u81", Username: "reviewer1", IsActive: true},
+			{ID: "u82", Username: "reviewer2", IsActive: true},
+			{ID: "u83", Username: "reviewer3", IsActive: true},
+		}
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "tx-team", Members: members}))
+
+		created, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-400", Name: "Tx", AuthorID: "u80"})
+		require.NoError(t, err)
+		require.Len(t, created.AssignedReviewers, 2)
+
+		leaving := created.AssignedReviewers[0]
+
+		user, reassigned, err := userService.DeactivateAndReassign(ctx, leaving)
+		require.NoError(t, err)
+		assert.False(t, user.IsActive)
+		require.Len(t, reassigned, 1)
+		assert.NotContains(t, reassigned[0].AssignedReviewers, leaving)
+		assert.Len(t, reassigned[0].AssignedReviewers, 2)
+	})
+
+	t.Run("deactivate and reassign rolls back without candidates", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+
+		members := []domain.User{
+			{ID: "u90", Username: "author", IsActive: true},
+			{ID: "u91", Username: "reviewer1", IsActive: true},
+			{ID: "u92", Username: "reviewer2", IsActive: true},
+		}
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "tx-small-team", Members: members}))
+
+		_, err := prService.Create(ctx, domain.PullRequestCreate{ID: "pr-401", Name: "Tx", AuthorID: "u90"})
+		require.NoError(t, err)
+
+		_, _, err = userService.DeactivateAndReassign(ctx, "u91")
+		assert.ErrorIs(t, err, domain.ErrNoCandidate)
+
+		user, err := userTeamRepo.GetUserByID(ctx, "u91")
+		require.NoError(t, err)
+		assert.True(t, user.IsActive)
+	})
+
+	t.Run("transaction rolls back on panic", func(t *testing.T) {
+		require.NoError(t, cleanupDatabase(db))
+		require.NoError(t, teamService.Create(ctx, domain.Team{Name: "dev-team", Members: members}))
+
+		assert.Panics(t, func() {
+			_ = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, err := userService.SetActive(ctx, "u40", false)
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+
+		user, err := userTeamRepo.GetUserByID(ctx, "u40")
+		require.NoError(t, err)
+		assert.True(t, user.IsActive)
+	})
+
 	err = cleanupDatabase(db)
 	require.NoError(t, err)
 }